@@ -0,0 +1,289 @@
+package catalogue
+
+import (
+	"fmt"
+	"math"
+	"ptcgpocket/data"
+	"slices"
+)
+
+type ChangeKind uint8
+
+const (
+	ExpansionAdded ChangeKind = iota
+	ExpansionRemoved
+	BoosterAdded
+	BoosterRemoved
+	BoosterCardsChanged
+	CardAdded
+	CardRemoved
+	CardRenumbered
+	CardRenamed
+	CardRarityChanged
+	CardDetailsChanged
+	OfferingRateChanged
+	PackRateChanged
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ExpansionAdded:
+		return "expansion added"
+	case ExpansionRemoved:
+		return "expansion removed"
+	case BoosterAdded:
+		return "booster added"
+	case BoosterRemoved:
+		return "booster removed"
+	case BoosterCardsChanged:
+		return "booster cards"
+	case CardAdded:
+		return "card added"
+	case CardRemoved:
+		return "card removed"
+	case CardRenumbered:
+		return "card renumbered"
+	case CardRenamed:
+		return "card renamed"
+	case CardRarityChanged:
+		return "rarity"
+	case CardDetailsChanged:
+		return "card details"
+	case OfferingRateChanged:
+		return "offering rate"
+	case PackRateChanged:
+		return "pack rate"
+	}
+	return "unknown"
+}
+
+// Offering rates closer than this are the same, allowing for float noise.
+const rateTolerance = 1e-9
+
+type Change struct {
+	kind        ChangeKind
+	expansionId data.ExpansionId
+	description string
+}
+
+func (c *Change) Kind() ChangeKind {
+	return c.kind
+}
+
+func (c *Change) ExpansionId() data.ExpansionId {
+	return c.expansionId
+}
+
+func (c *Change) Description() string {
+	return c.description
+}
+
+func (c *Change) String() string {
+	return fmt.Sprintf("[%v] %v %v", c.kind, c.expansionId, c.description)
+}
+
+type snapshotDiff struct {
+	changes []*Change
+}
+
+func (d *snapshotDiff) add(kind ChangeKind, expansionId data.ExpansionId, format string, args ...any) {
+	d.changes = append(d.changes, &Change{
+		kind:        kind,
+		expansionId: expansionId,
+		description: fmt.Sprintf(format, args...),
+	})
+}
+
+func findBy[T any](items []*T, matches func(*T) bool) *T {
+	i := slices.IndexFunc(items, matches)
+	if i == -1 {
+		return nil
+	}
+	return items[i]
+}
+
+// Changes from the old snapshot to the new, by expansion in the new
+// snapshot's order followed by removed expansions.
+func DiffSnapshots(old *Snapshot, new *Snapshot) []*Change {
+	diff := &snapshotDiff{}
+	for _, ne := range new.snapshot.Expansions {
+		oe := findBy(old.snapshot.Expansions, func(e *serialisedExpansion) bool {
+			return e.Id == ne.Id
+		})
+		if oe == nil {
+			diff.add(ExpansionAdded, ne.Id, "%v (%v cards)", ne.Name, len(ne.Cards))
+			continue
+		}
+		diff.diffCards(ne.Id, oe.Cards, ne.Cards)
+		diff.diffBoosters(ne.Id, oe.Boosters, ne.Boosters)
+	}
+	for _, oe := range old.snapshot.Expansions {
+		if !slices.ContainsFunc(new.snapshot.Expansions, func(e *serialisedExpansion) bool {
+			return e.Id == oe.Id
+		}) {
+			diff.add(ExpansionRemoved, oe.Id, "%v", oe.Name)
+		}
+	}
+	return diff.changes
+}
+
+func (d *snapshotDiff) diffCards(expansionId data.ExpansionId, oldCards []*serialisedCard, newCards []*serialisedCard) {
+	var added, removed []*serialisedCard
+	for _, nc := range newCards {
+		oc := findBy(oldCards, func(c *serialisedCard) bool {
+			return c.Number == nc.Number
+		})
+		if oc == nil {
+			added = append(added, nc)
+			continue
+		}
+		if oc.Name != nc.Name {
+			d.add(CardRenamed, expansionId, "#%v %v -> %v", nc.Number, oc.Name, nc.Name)
+		}
+		if oc.Rarity != nc.Rarity {
+			d.add(CardRarityChanged, expansionId, "#%v %v %v -> %v", nc.Number, nc.Name, oc.Rarity, nc.Rarity)
+		}
+		if oc.details() != nc.details() {
+			d.add(CardDetailsChanged, expansionId, "#%v %v %v -> %v", nc.Number, nc.Name, oc.details(), nc.details())
+		}
+	}
+	for _, oc := range oldCards {
+		if !slices.ContainsFunc(newCards, func(c *serialisedCard) bool {
+			return c.Number == oc.Number
+		}) {
+			removed = append(removed, oc)
+		}
+	}
+
+	// A card removed and added elsewhere with the same name and rarity moved
+	for _, rc := range removed {
+		i := slices.IndexFunc(added, func(c *serialisedCard) bool {
+			return c.Name == rc.Name && c.Rarity == rc.Rarity
+		})
+		if i == -1 {
+			d.add(CardRemoved, expansionId, "#%v %v %v", rc.Number, rc.Rarity, rc.Name)
+			continue
+		}
+		d.add(CardRenumbered, expansionId, "%v %v #%v -> #%v", rc.Rarity, rc.Name, rc.Number, added[i].Number)
+		added = slices.Delete(added, i, i+1)
+	}
+	for _, ac := range added {
+		d.add(CardAdded, expansionId, "#%v %v %v", ac.Number, ac.Rarity, ac.Name)
+	}
+}
+
+func (c *serialisedCard) details() string {
+	return fmt.Sprintf("%v %v %v %vHP retreat %v", c.Kind, c.Stage, c.EnergyType, c.Health, c.RetreatCost)
+}
+
+func ratesDiffer(r1 float64, r2 float64) bool {
+	return math.Abs(r1-r2) > rateTolerance
+}
+
+func (d *snapshotDiff) diffBoosters(
+	expansionId data.ExpansionId,
+	oldBoosters []*serialisedBooster,
+	newBoosters []*serialisedBooster,
+) {
+	for _, nb := range newBoosters {
+		ob := findBy(oldBoosters, func(b *serialisedBooster) bool {
+			return b.Name == nb.Name
+		})
+		if ob == nil {
+			d.add(BoosterAdded, expansionId, "%v (%v cards)", nb.Name, len(nb.Cards))
+			continue
+		}
+
+		var cardsAdded, cardsRemoved []data.ExpansionCardNumber
+		for _, n := range nb.Cards {
+			if !slices.Contains(ob.Cards, n) {
+				cardsAdded = append(cardsAdded, n)
+			}
+		}
+		for _, n := range ob.Cards {
+			if !slices.Contains(nb.Cards, n) {
+				cardsRemoved = append(cardsRemoved, n)
+			}
+		}
+		if len(cardsAdded) > 0 || len(cardsRemoved) > 0 {
+			d.add(BoosterCardsChanged, expansionId, "%v added %v, removed %v", nb.Name, cardsAdded, cardsRemoved)
+		}
+
+		d.diffOfferings(expansionId, nb.Name, ob.OfferingRates, nb.OfferingRates)
+
+		packRates := []struct {
+			name     string
+			old, new float64
+		}{
+			{"regular pack", ob.RegularPackRate, nb.RegularPackRate},
+			{"regular+1 pack", ob.RegularPackPlusOneRate, nb.RegularPackPlusOneRate},
+			{"rare pack", ob.RarePackRate, nb.RarePackRate},
+		}
+		for _, r := range packRates {
+			if ratesDiffer(r.old, r.new) {
+				d.add(PackRateChanged, expansionId, "%v %v %v -> %v", nb.Name, r.name, r.old, r.new)
+			}
+		}
+		if ob.RarePackCrownExclusive != nb.RarePackCrownExclusive {
+			d.add(
+				PackRateChanged,
+				expansionId,
+				"%v rare pack crown #%v -> #%v",
+				nb.Name,
+				ob.RarePackCrownExclusive,
+				nb.RarePackCrownExclusive,
+			)
+		}
+	}
+	for _, ob := range oldBoosters {
+		if !slices.ContainsFunc(newBoosters, func(b *serialisedBooster) bool {
+			return b.Name == ob.Name
+		}) {
+			d.add(BoosterRemoved, expansionId, "%v", ob.Name)
+		}
+	}
+}
+
+func (d *snapshotDiff) diffOfferings(
+	expansionId data.ExpansionId,
+	boosterName string,
+	oldOfferings []*serialisedOffering,
+	newOfferings []*serialisedOffering,
+) {
+	notPresent := &serialisedOffering{}
+	var rarities []string
+	for _, o := range slices.Concat(oldOfferings, newOfferings) {
+		if !slices.Contains(rarities, o.Rarity) {
+			rarities = append(rarities, o.Rarity)
+		}
+	}
+	for _, r := range rarities {
+		matches := func(o *serialisedOffering) bool {
+			return o.Rarity == r
+		}
+		oo := offeringOr(findBy(oldOfferings, matches), notPresent)
+		no := offeringOr(findBy(newOfferings, matches), notPresent)
+
+		slots := []struct {
+			name     string
+			old, new float64
+		}{
+			{"1-3", oo.First3, no.First3},
+			{"4", oo.Fourth, no.Fourth},
+			{"5", oo.Fifth, no.Fifth},
+			{"rare", oo.Rare, no.Rare},
+		}
+		for _, s := range slots {
+			if ratesDiffer(s.old, s.new) {
+				d.add(OfferingRateChanged, expansionId, "%v %v slot %v %v%% -> %v%%", boosterName, r, s.name, s.old, s.new)
+			}
+		}
+	}
+}
+
+func offeringOr(o *serialisedOffering, fallback *serialisedOffering) *serialisedOffering {
+	if o == nil {
+		return fallback
+	}
+	return o
+}
@@ -0,0 +1,11 @@
+//go:build embedcatalogue
+
+package catalogue
+
+import _ "embed"
+
+// Built with -tags embedcatalogue after exporting the catalogue to
+// catalogue/snapshot.json.gz.
+//
+//go:embed snapshot.json.gz
+var embeddedSnapshot []byte
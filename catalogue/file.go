@@ -0,0 +1,56 @@
+package catalogue
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"strings"
+)
+
+func ReadSnapshotFile(path string) (*Snapshot, error) {
+	f, oErr := os.Open(path)
+	if oErr != nil {
+		return nil, oErr
+	}
+	defer f.Close()
+	return ReadSnapshot(f)
+}
+
+// Writes the snapshot to the file, gzipped when the path ends in .gz.
+func (s *Snapshot) WriteFile(path string) error {
+	f, cErr := os.Create(path)
+	if cErr != nil {
+		return cErr
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		if wErr := s.Write(f); wErr != nil {
+			f.Close()
+			return wErr
+		}
+		return f.Close()
+	}
+
+	gz := gzip.NewWriter(f)
+	if wErr := s.Write(gz); wErr != nil {
+		f.Close()
+		return wErr
+	}
+	if gErr := gz.Close(); gErr != nil {
+		f.Close()
+		return gErr
+	}
+	return f.Close()
+}
+
+// Whether the binary was built with a snapshot embedded.
+func HasEmbeddedSnapshot() bool {
+	return len(embeddedSnapshot) > 0
+}
+
+func ReadEmbeddedSnapshot() (*Snapshot, error) {
+	if !HasEmbeddedSnapshot() {
+		return nil, errors.New("no snapshot embedded, build with -tags embedcatalogue")
+	}
+	return ReadSnapshot(bytes.NewReader(embeddedSnapshot))
+}
@@ -0,0 +1,5 @@
+//go:build !embedcatalogue
+
+package catalogue
+
+var embeddedSnapshot []byte
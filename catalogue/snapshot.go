@@ -0,0 +1,251 @@
+package catalogue
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"ptcgpocket/data"
+	"slices"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Bumped when the snapshot format changes incompatibly.
+const SnapshotVersion = 1
+
+type serialisedCard struct {
+	Number      data.ExpansionCardNumber `json:"number"`
+	Name        string                   `json:"name"`
+	Rarity      string                   `json:"rarity"`
+	Health      uint8                    `json:"health"`
+	RetreatCost uint8                    `json:"retreatCost"`
+	Kind        string                   `json:"kind"`
+	Stage       string                   `json:"stage"`
+	EnergyType  data.EnergyType          `json:"energyType,omitempty"`
+}
+
+type serialisedOffering struct {
+	Rarity string  `json:"rarity"`
+	First3 float64 `json:"first3"`
+	Fourth float64 `json:"fourth"`
+	Fifth  float64 `json:"fifth"`
+	Rare   float64 `json:"rare"`
+}
+
+type serialisedBooster struct {
+	Name                   string                     `json:"name"`
+	Cards                  []data.ExpansionCardNumber `json:"cards"`
+	OfferingRates          []*serialisedOffering      `json:"offeringRates"`
+	RarePackCrownExclusive data.ExpansionCardNumber   `json:"rarePackCrownExclusive"`
+	RegularPackRate        float64                    `json:"regularPackRate"`
+	RegularPackPlusOneRate float64                    `json:"regularPackPlusOneRate"`
+	RarePackRate           float64                    `json:"rarePackRate"`
+}
+
+type serialisedExpansion struct {
+	Id       data.ExpansionId     `json:"id"`
+	Name     string               `json:"name"`
+	Code     string               `json:"code"`
+	Cards    []*serialisedCard    `json:"cards"`
+	Boosters []*serialisedBooster `json:"boosters"`
+}
+
+type serialisedSnapshot struct {
+	Version    int                    `json:"version"`
+	Expansions []*serialisedExpansion `json:"expansions"`
+}
+
+// The parsed catalogue of every expansion, in a canonical form so snapshots
+// of the same data are byte for byte identical.
+type Snapshot struct {
+	snapshot *serialisedSnapshot
+}
+
+func serialiseBooster(b *data.Booster) *serialisedBooster {
+	var cards []data.ExpansionCardNumber
+	for c := range b.Cards() {
+		cards = append(cards, c.Number())
+	}
+	slices.Sort(cards)
+
+	offeringRates := b.OfferingRates()
+	var offerings []*serialisedOffering
+	for r := range data.Rarities.All() {
+		o, oFound := offeringRates[r]
+		if !oFound {
+			continue
+		}
+		offerings = append(offerings, &serialisedOffering{
+			Rarity: r.String(),
+			First3: o.First3CardOffering(),
+			Fourth: o.FourthCardOffering(),
+			Fifth:  o.FifthCardOffering(),
+			Rare:   o.RareOffering(),
+		})
+	}
+
+	return &serialisedBooster{
+		Name:                   b.Name(),
+		Cards:                  cards,
+		OfferingRates:          offerings,
+		RarePackCrownExclusive: b.RarePackCrownExclusiveExpansionNumber(),
+		RegularPackRate:        b.RegularPackRate(),
+		RegularPackPlusOneRate: b.RegularPackPlusOneRate(),
+		RarePackRate:           b.RarePackRate(),
+	}
+}
+
+func NewSnapshot(expansions []*data.Expansion) *Snapshot {
+	snapshot := &serialisedSnapshot{Version: SnapshotVersion}
+	for _, e := range expansions {
+		se := &serialisedExpansion{Id: e.Id(), Name: e.Name(), Code: e.Code()}
+		for c := range e.Cards() {
+			se.Cards = append(se.Cards, &serialisedCard{
+				Number:      c.Number(),
+				Name:        c.Name(),
+				Rarity:      c.Rarity().String(),
+				Health:      c.Base().Health(),
+				RetreatCost: c.Base().RetreatCost(),
+				Kind:        c.Base().Kind().String(),
+				Stage:       c.Base().Stage().String(),
+				EnergyType:  c.Base().EnergyType(),
+			})
+		}
+		slices.SortFunc(se.Cards, func(c1, c2 *serialisedCard) int {
+			return cmp.Compare(c1.Number, c2.Number)
+		})
+		for b := range e.Boosters() {
+			se.Boosters = append(se.Boosters, serialiseBooster(b))
+		}
+		snapshot.Expansions = append(snapshot.Expansions, se)
+	}
+	return &Snapshot{snapshot: snapshot}
+}
+
+// Writes the snapshot as indented JSON.
+func (s *Snapshot) Write(w io.Writer) error {
+	raw, mErr := json.MarshalIndent(s.snapshot, "", "  ")
+	if mErr != nil {
+		return mErr
+	}
+	_, wErr := w.Write(append(raw, '\n'))
+	return wErr
+}
+
+// Reads a snapshot written by Write, gzipped or not.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	buffered := bufio.NewReader(r)
+	var source io.Reader = buffered
+	if magic, _ := buffered.Peek(len(gzipMagic)); bytes.Equal(magic, gzipMagic) {
+		gz, gErr := gzip.NewReader(buffered)
+		if gErr != nil {
+			return nil, fmt.Errorf("reading snapshot: %w", gErr)
+		}
+		defer gz.Close()
+		source = gz
+	}
+
+	var snapshot serialisedSnapshot
+	if dErr := json.NewDecoder(source).Decode(&snapshot); dErr != nil {
+		return nil, fmt.Errorf("reading snapshot: %w", dErr)
+	}
+	if snapshot.Version != SnapshotVersion {
+		return nil, fmt.Errorf("snapshot version %v, want %v", snapshot.Version, SnapshotVersion)
+	}
+	return &Snapshot{snapshot: &snapshot}, nil
+}
+
+func (c *serialisedCard) baseCard() (*data.BaseCard, error) {
+	kind, kErr := data.ParseCardKind(c.Kind)
+	if kErr != nil {
+		return nil, kErr
+	}
+	switch kind {
+	case data.CardKindTrainer:
+		return data.NewTrainerBaseCard(c.Name), nil
+	case data.CardKindPokemon:
+		stage, sErr := data.ParseStage(c.Stage)
+		if sErr != nil {
+			return nil, sErr
+		}
+		energyType := data.EnergyTypeUnknown
+		if c.EnergyType != "" {
+			var eErr error
+			energyType, eErr = data.ParseEnergyType(string(c.EnergyType))
+			if eErr != nil {
+				return nil, eErr
+			}
+		}
+		return data.NewPokemonBaseCard(c.Name, c.Health, c.RetreatCost, stage, energyType), nil
+	}
+	return data.NewBaseCard(c.Name, c.Health, c.RetreatCost), nil
+}
+
+func (se *serialisedExpansion) expansion(boosterOptions *data.BoosterOptions) (*data.Expansion, error) {
+	cardsByNumber := make(map[data.ExpansionCardNumber]*data.Card)
+	for _, sc := range se.Cards {
+		base, bErr := sc.baseCard()
+		if bErr != nil {
+			return nil, fmt.Errorf("%v #%v: %w", se.Id, sc.Number, bErr)
+		}
+		rarity, rErr := data.Rarities.BySymbol(sc.Rarity)
+		if rErr != nil {
+			return nil, fmt.Errorf("%v #%v: %w", se.Id, sc.Number, rErr)
+		}
+		cardsByNumber[sc.Number] = data.NewCard(base, sc.Number, rarity)
+	}
+
+	var boosters []*data.Booster
+	for _, sb := range se.Boosters {
+		var cards []*data.Card
+		for _, n := range sb.Cards {
+			c, cFound := cardsByNumber[n]
+			if !cFound {
+				return nil, fmt.Errorf("%v %v: card #%v not in the expansion", se.Id, sb.Name, n)
+			}
+			cards = append(cards, c)
+		}
+
+		offeringRates := make(data.OfferingRatesTable)
+		for _, so := range sb.OfferingRates {
+			rarity, rErr := data.Rarities.BySymbol(so.Rarity)
+			if rErr != nil {
+				return nil, fmt.Errorf("%v %v: %w", se.Id, sb.Name, rErr)
+			}
+			offeringRates[rarity] = *data.NewBoosterOffering(so.First3, so.Fourth, so.Fifth, so.Rare)
+		}
+
+		booster, bErr := data.NewBooster(
+			sb.Name,
+			cards,
+			offeringRates,
+			sb.RarePackCrownExclusive,
+			sb.RegularPackRate,
+			sb.RegularPackPlusOneRate,
+			sb.RarePackRate,
+			boosterOptions,
+		)
+		if bErr != nil {
+			return nil, fmt.Errorf("%v: %w", se.Id, bErr)
+		}
+		boosters = append(boosters, booster)
+	}
+	return data.NewExpansion(se.Id, se.Name, se.Code, boosters), nil
+}
+
+// Rebuilds every expansion in the snapshot, in the snapshot's order.
+func (s *Snapshot) Expansions(boosterOptions *data.BoosterOptions) ([]*data.Expansion, error) {
+	var expansions []*data.Expansion
+	for _, se := range s.snapshot.Expansions {
+		e, eErr := se.expansion(boosterOptions)
+		if eErr != nil {
+			return nil, eErr
+		}
+		expansions = append(expansions, e)
+	}
+	return expansions, nil
+}
@@ -0,0 +1,124 @@
+package catalogue
+
+import (
+	"bytes"
+	"path/filepath"
+	"ptcgpocket/data"
+	"ptcgpocket/internal/testexpansion"
+	"testing"
+)
+
+// Genetic Apex with its fifth card slot split between one and four diamonds.
+func newSnapshotTestExpansion(cards []*data.Card, fifthOneDiamond float64) *data.Expansion {
+	return testexpansion.NewWithRates("genetic-apex", "Genetic Apex", "A1", cards, data.OfferingRatesTable{
+		data.RarityOneDiamond:  *data.NewBoosterOffering(100.0, 100.0, fifthOneDiamond, 100.0),
+		data.RarityFourDiamond: *data.NewBoosterOffering(0, 0, 100.0-fifthOneDiamond, 0),
+		data.RarityOneStar:     *data.NewBoosterOffering(0, 0, 0, 0),
+	})
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	potion := data.NewCard(data.NewTrainerBaseCard("Potion"), 1, data.RarityOneDiamond)
+	pikachu := data.NewCard(
+		data.NewPokemonBaseCard("Pikachu ex", 120, 1, data.StageBasic, data.EnergyTypeLightning),
+		2,
+		data.RarityFourDiamond,
+	)
+	snapshot := NewSnapshot([]*data.Expansion{newSnapshotTestExpansion([]*data.Card{pikachu, potion}, 100.0)})
+
+	var written bytes.Buffer
+	if err := snapshot.Write(&written); err != nil {
+		t.Fatalf("Write error = %v; want nil", err)
+	}
+	read, rErr := ReadSnapshot(bytes.NewReader(written.Bytes()))
+	if rErr != nil {
+		t.Fatalf("ReadSnapshot error = %v; want nil", rErr)
+	}
+	var rewritten bytes.Buffer
+	read.Write(&rewritten)
+	if !bytes.Equal(written.Bytes(), rewritten.Bytes()) {
+		t.Errorf("Rewritten snapshot = %s; want %s", rewritten.Bytes(), written.Bytes())
+	}
+	if changes := DiffSnapshots(snapshot, read); len(changes) != 0 {
+		t.Errorf("DiffSnapshots of identical snapshots = %v; want none", changes)
+	}
+
+	if _, err := ReadSnapshot(bytes.NewReader([]byte(`{"version": 99}`))); err == nil {
+		t.Errorf("ReadSnapshot of unknown version error = nil; want error")
+	}
+}
+
+func TestSnapshotExpansions(t *testing.T) {
+	pikachu := data.NewCard(
+		data.NewPokemonBaseCard("Pikachu ex", 120, 1, data.StageBasic, data.EnergyTypeLightning),
+		2,
+		data.RarityFourDiamond,
+	)
+	potion := data.NewCard(data.NewTrainerBaseCard("Potion"), 1, data.RarityOneDiamond)
+	expansion := newSnapshotTestExpansion([]*data.Card{pikachu, potion}, 100.0)
+	snapshot := NewSnapshot([]*data.Expansion{expansion})
+
+	path := filepath.Join(t.TempDir(), "catalogue.json.gz")
+	if err := snapshot.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile error = %v; want nil", err)
+	}
+	read, rErr := ReadSnapshotFile(path)
+	if rErr != nil {
+		t.Fatalf("ReadSnapshotFile error = %v; want nil", rErr)
+	}
+	expansions, eErr := read.Expansions(data.DefaultBoosterOptions)
+	if eErr != nil {
+		t.Fatalf("Expansions error = %v; want nil", eErr)
+	}
+	if len(expansions) != 1 || expansions[0].Code() != "A1" || expansions[0].TotalCards() != 2 {
+		t.Fatalf("Expansions = %v; want A1 with 2 cards", expansions)
+	}
+	rebuilt, cErr := expansions[0].GetCardByNumber(2)
+	if cErr != nil {
+		t.Fatalf("GetCardByNumber error = %v; want nil", cErr)
+	}
+	if !rebuilt.Base().IsEqual(pikachu.Base()) || rebuilt.Rarity() != data.RarityFourDiamond {
+		t.Errorf("Rebuilt card = %v %v; want %v %v", rebuilt.Name(), rebuilt.Rarity(), pikachu.Name(), pikachu.Rarity())
+	}
+	if changes := DiffSnapshots(snapshot, NewSnapshot(expansions)); len(changes) != 0 {
+		t.Errorf("DiffSnapshots of rebuilt expansions = %v; want none", changes)
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	potion := data.NewCard(data.NewTrainerBaseCard("Potion"), 1, data.RarityOneDiamond)
+	pikachuBase := data.NewPokemonBaseCard("Pikachu ex", 120, 1, data.StageBasic, data.EnergyTypeLightning)
+	old := NewSnapshot([]*data.Expansion{newSnapshotTestExpansion([]*data.Card{
+		potion,
+		data.NewCard(pikachuBase, 2, data.RarityFourDiamond),
+		data.NewCard(data.NewTrainerBaseCard("Poke Ball"), 3, data.RarityOneDiamond),
+		data.NewCard(data.NewTrainerBaseCard("X Speed"), 4, data.RarityOneDiamond),
+	}, 100.0)})
+	new := NewSnapshot([]*data.Expansion{newSnapshotTestExpansion([]*data.Card{
+		potion,
+		data.NewCard(pikachuBase, 5, data.RarityFourDiamond),
+		data.NewCard(data.NewTrainerBaseCard("Poké Ball"), 3, data.RarityOneDiamond),
+		data.NewCard(data.NewTrainerBaseCard("X Speed"), 4, data.RarityOneStar),
+	}, 95.0)})
+
+	counts := make(map[ChangeKind]int)
+	for _, c := range DiffSnapshots(old, new) {
+		counts[c.Kind()]++
+	}
+	want := map[ChangeKind]int{
+		CardRenumbered:      1,
+		CardRenamed:         1,
+		CardRarityChanged:   1,
+		BoosterCardsChanged: 1,
+		// One diamond and four diamond 5th card rates
+		OfferingRateChanged: 2,
+	}
+	for kind, n := range want {
+		if counts[kind] != n {
+			t.Errorf("%v changes = %v; want %v", kind, counts[kind], n)
+		}
+	}
+	if len(counts) != len(want) {
+		t.Errorf("Change kinds = %v; want %v", counts, want)
+	}
+}
@@ -0,0 +1,229 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Offering totals within this many percentage points of 100 are considered
+// valid. Official numbers are published rounded to 3 decimal places.
+const OfferingTotalTolerance = 0.1
+
+type OfferingSlot string
+
+const (
+	OfferingSlotFirst3 OfferingSlot = "1-3"
+	OfferingSlotFourth OfferingSlot = "4"
+	OfferingSlotFifth  OfferingSlot = "5"
+	OfferingSlotRare   OfferingSlot = "rare"
+)
+
+var OrderedOfferingSlots = []OfferingSlot{
+	OfferingSlotFirst3,
+	OfferingSlotFourth,
+	OfferingSlotFifth,
+	OfferingSlotRare,
+}
+
+func (s OfferingSlot) offering(o *BoosterCardOffering) float64 {
+	switch s {
+	case OfferingSlotFirst3:
+		return o.first3CardOffering
+	case OfferingSlotFourth:
+		return o.fourthCardOffering
+	case OfferingSlotFifth:
+		return o.fifthCardOffering
+	case OfferingSlotRare:
+		return o.rareCardOffering
+	}
+	panic(fmt.Sprintf("unknown offering slot %v", string(s)))
+}
+
+func (s OfferingSlot) scaleOffering(o *BoosterCardOffering, factor float64) {
+	switch s {
+	case OfferingSlotFirst3:
+		o.first3CardOffering *= factor
+	case OfferingSlotFourth:
+		o.fourthCardOffering *= factor
+	case OfferingSlotFifth:
+		o.fifthCardOffering *= factor
+	case OfferingSlotRare:
+		o.rareCardOffering *= factor
+	default:
+		panic(fmt.Sprintf("unknown offering slot %v", string(s)))
+	}
+}
+
+type OfferingSlotAudit struct {
+	slot         OfferingSlot
+	total        float64
+	rarityTotals map[*Rarity]float64
+}
+
+func (a *OfferingSlotAudit) Slot() OfferingSlot {
+	return a.slot
+}
+
+// Sum of every card's chance of appearing in this slot, as a percentage.
+func (a *OfferingSlotAudit) Total() float64 {
+	return a.total
+}
+
+// Percentage points the total is over (positive) or under (negative) 100.
+func (a *OfferingSlotAudit) Deviation() float64 {
+	return a.total - 100.0
+}
+
+func (a *OfferingSlotAudit) IsValid() bool {
+	return math.Abs(a.Deviation()) <= OfferingTotalTolerance
+}
+
+func (a *OfferingSlotAudit) RarityTotal(rarity *Rarity) float64 {
+	return a.rarityTotals[rarity]
+}
+
+// Rarities with a non-zero total, in rarity order.
+func (a *OfferingSlotAudit) RarityTotals() iter.Seq2[*Rarity, float64] {
+	return func(yield func(*Rarity, float64) bool) {
+		for r := range Rarities.All() {
+			t := a.rarityTotals[r]
+			if t == 0 {
+				continue
+			}
+			if !yield(r, t) {
+				return
+			}
+		}
+	}
+}
+
+type BoosterAudit struct {
+	boosterName string
+	slots       []*OfferingSlotAudit
+}
+
+func AuditOfferings(boosterName string, offerings []*BoosterCardOffering) *BoosterAudit {
+	slots := make([]*OfferingSlotAudit, len(OrderedOfferingSlots))
+	for i, s := range OrderedOfferingSlots {
+		slotAudit := &OfferingSlotAudit{
+			slot:         s,
+			rarityTotals: make(map[*Rarity]float64),
+		}
+		for _, o := range offerings {
+			v := s.offering(o)
+			slotAudit.total += v
+			slotAudit.rarityTotals[o.card.rarity] += v
+		}
+		slots[i] = slotAudit
+	}
+	return &BoosterAudit{boosterName: boosterName, slots: slots}
+}
+
+func (a *BoosterAudit) BoosterName() string {
+	return a.boosterName
+}
+
+func (a *BoosterAudit) Slots() iter.Seq[*OfferingSlotAudit] {
+	return slices.Values(a.slots)
+}
+
+func (a *BoosterAudit) Slot(slot OfferingSlot) *OfferingSlotAudit {
+	for _, s := range a.slots {
+		if s.slot == slot {
+			return s
+		}
+	}
+	return nil
+}
+
+func (a *BoosterAudit) IsValid() bool {
+	for _, s := range a.slots {
+		if !s.IsValid() {
+			return false
+		}
+	}
+	return true
+}
+
+// Err describes every slot outside of tolerance, or nil if all are valid.
+func (a *BoosterAudit) Err() error {
+	var invalid []string
+	for _, s := range a.slots {
+		if !s.IsValid() {
+			invalid = append(invalid, fmt.Sprintf("%v totals %.3f%% (%+.3f)", s.slot, s.total, s.Deviation()))
+		}
+	}
+	if len(invalid) == 0 {
+		return nil
+	}
+	return fmt.Errorf("offerings for %v don't total 100%%: %v", a.boosterName, strings.Join(invalid, ", "))
+}
+
+// What to do when a booster's offering table doesn't total 100% for a slot.
+type OfferingDriftPolicy uint8
+
+const (
+	// Scale each slot so it totals exactly 100%, keeping the relative
+	// chances between cards.
+	OfferingDriftRenormalise OfferingDriftPolicy = iota
+	// Use the published numbers unchanged. Sampling can only ever pick
+	// relative to the slot total, so instance probabilities and sampled
+	// boosters will disagree by the slot's deviation.
+	OfferingDriftAsIs
+	// Refuse to create the booster.
+	OfferingDriftFail
+)
+
+var offeringDriftPolicyNames = map[OfferingDriftPolicy]string{
+	OfferingDriftRenormalise: "renormalise",
+	OfferingDriftAsIs:        "as-is",
+	OfferingDriftFail:        "fail",
+}
+
+func ParseOfferingDriftPolicy(value string) (OfferingDriftPolicy, error) {
+	for p, n := range offeringDriftPolicyNames {
+		if n == value {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown offering drift policy '%v'", value)
+}
+
+func (p OfferingDriftPolicy) String() string {
+	return offeringDriftPolicyNames[p]
+}
+
+var ErrOfferingDrift = errors.New("offering drift")
+
+func applyOfferingDriftPolicy(
+	policy OfferingDriftPolicy,
+	audit *BoosterAudit,
+	offerings []*BoosterCardOffering,
+) error {
+	switch policy {
+	case OfferingDriftAsIs:
+		return nil
+	case OfferingDriftFail:
+		err := audit.Err()
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrOfferingDrift, err)
+		}
+		return nil
+	case OfferingDriftRenormalise:
+		for _, s := range audit.slots {
+			if s.total == 0 {
+				continue
+			}
+			factor := 100.0 / s.total
+			for _, o := range offerings {
+				s.slot.scaleOffering(o, factor)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown offering drift policy %d", policy)
+}
@@ -0,0 +1,86 @@
+package data
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func newDriftingTestBooster(policy OfferingDriftPolicy) (*Booster, error) {
+	return NewBooster(
+		"Drifting booster",
+		[]*Card{
+			NewCard(NewBaseCard("Pikachu", 60, 1), 1, RarityOneDiamond),
+			NewCard(NewBaseCard("Raichu", 100, 1), 2, RarityTwoDiamond),
+			NewCard(NewBaseCard("Pikachu ex", 120, 1), 3, RarityFourDiamond),
+		},
+		OfferingRatesTable{
+			RarityOneDiamond:  *NewBoosterOffering(100.0, 0, 0, 0),
+			RarityTwoDiamond:  *NewBoosterOffering(0, 90.0, 60.0, 0),
+			RarityFourDiamond: *NewBoosterOffering(0, 5.0, 20.0, 100.0),
+		},
+		0,
+		0.9995,
+		0,
+		0.0005,
+		NewBoosterOptions(policy, SamplingAlias),
+	)
+}
+
+func TestAuditOfferings(t *testing.T) {
+	booster, err := newDriftingTestBooster(OfferingDriftAsIs)
+	if err != nil {
+		t.Fatalf("NewBooster returned error %v", err)
+	}
+
+	audit := booster.Audit()
+	if audit.IsValid() {
+		t.Errorf("Audit valid = true; want false")
+	}
+	fourth := audit.Slot(OfferingSlotFourth)
+	if fourth.Total() != 95.0 {
+		t.Errorf("Fourth slot total = %v; want 95", fourth.Total())
+	}
+	if fourth.Deviation() != -5.0 {
+		t.Errorf("Fourth slot deviation = %v; want -5", fourth.Deviation())
+	}
+	if fourth.RarityTotal(RarityTwoDiamond) != 90.0 {
+		t.Errorf("Fourth slot two diamond total = %v; want 90", fourth.RarityTotal(RarityTwoDiamond))
+	}
+	if !audit.Slot(OfferingSlotFirst3).IsValid() {
+		t.Errorf("First 3 slot valid = false; want true")
+	}
+	if audit.Slot(OfferingSlotFifth).Deviation() != -20.0 {
+		t.Errorf("Fifth slot deviation = %v; want -20", audit.Slot(OfferingSlotFifth).Deviation())
+	}
+}
+
+func TestOfferingDriftRenormalise(t *testing.T) {
+	booster, err := newDriftingTestBooster(OfferingDriftRenormalise)
+	if err != nil {
+		t.Fatalf("NewBooster returned error %v", err)
+	}
+
+	// The audit still reports the source numbers
+	if booster.Audit().Slot(OfferingSlotFourth).Total() != 95.0 {
+		t.Errorf("Audit fourth slot total = %v; want 95", booster.Audit().Slot(OfferingSlotFourth).Total())
+	}
+
+	totalFourth := 0.0
+	for o := range booster.Offerings() {
+		totalFourth += o.FourthCardOffering()
+		if o.Card().Number() == 2 && math.Abs(o.FourthCardOffering()-90.0/0.95) > 1e-9 {
+			t.Errorf("Renormalised two diamond fourth = %v; want %v", o.FourthCardOffering(), 90.0/0.95)
+		}
+	}
+	if math.Abs(totalFourth-100.0) > 1e-9 {
+		t.Errorf("Renormalised fourth total = %v; want 100", totalFourth)
+	}
+}
+
+func TestOfferingDriftFail(t *testing.T) {
+	_, err := newDriftingTestBooster(OfferingDriftFail)
+	if !errors.Is(err, ErrOfferingDrift) {
+		t.Errorf("NewBooster error = %v; want ErrOfferingDrift", err)
+	}
+}
@@ -3,6 +3,7 @@ package data
 import (
 	"fmt"
 	"iter"
+	"maps"
 	"math/rand/v2"
 	"slices"
 )
@@ -30,6 +31,22 @@ func NewBoosterOffering(
 
 var NotPresentBoosterOffering = NewBoosterOffering(0, 0, 0, 0)
 
+func (o *BoosterOffering) First3CardOffering() float64 {
+	return o.first3CardOffering
+}
+
+func (o *BoosterOffering) FourthCardOffering() float64 {
+	return o.fourthCardOffering
+}
+
+func (o *BoosterOffering) FifthCardOffering() float64 {
+	return o.fifthCardOffering
+}
+
+func (o *BoosterOffering) RareOffering() float64 {
+	return o.rareOffering
+}
+
 const MaxPackPointsPerBooster uint16 = 2_500
 
 type BoosterCardOffering struct {
@@ -87,38 +104,30 @@ func (b *BoosterInstance) Cards() iter.Seq[*Card] {
 
 type OfferingRatesTable map[*Rarity]BoosterOffering
 
-type cardProbabilityEntry struct {
-	cumulativeProbability float64
-	card                  *Card
+// Choices for how a booster's offering table is turned into card draws.
+type BoosterOptions struct {
+	offeringDriftPolicy OfferingDriftPolicy
+	samplingMethod      SamplingMethod
 }
 
-type offeringProbabilityList struct {
-	// Should always be 100, but atm we have some odd data where it's less.
-	// Should solve that core issue one day.
-	totalProbability float64
-	entries          []*cardProbabilityEntry
+func NewBoosterOptions(
+	offeringDriftPolicy OfferingDriftPolicy,
+	samplingMethod SamplingMethod,
+) *BoosterOptions {
+	return &BoosterOptions{
+		offeringDriftPolicy: offeringDriftPolicy,
+		samplingMethod:      samplingMethod,
+	}
 }
 
-func (o *offeringProbabilityList) append(card *Card, probability float64) {
-	if probability == 0 {
-		return
-	}
+var DefaultBoosterOptions = NewBoosterOptions(OfferingDriftRenormalise, SamplingAlias)
 
-	o.totalProbability += probability
-	o.entries = append(o.entries, &cardProbabilityEntry{
-		cumulativeProbability: o.totalProbability,
-		card:                  card,
-	})
+func (o *BoosterOptions) OfferingDriftPolicy() OfferingDriftPolicy {
+	return o.offeringDriftPolicy
 }
 
-func (o *offeringProbabilityList) pickRandomCard(randomGenerator *rand.Rand) *Card {
-	num := randomGenerator.Float64() * o.totalProbability
-	for _, e := range o.entries {
-		if num <= e.cumulativeProbability {
-			return e.card
-		}
-	}
-	panic(fmt.Sprintf("Invalid algorithm %v num %v total", num, o.totalProbability))
+func (o *BoosterOptions) SamplingMethod() SamplingMethod {
+	return o.samplingMethod
 }
 
 // type PackType interface {
@@ -127,11 +136,14 @@ func (o *offeringProbabilityList) pickRandomCard(randomGenerator *rand.Rand) *Ca
 type Booster struct {
 	name                   string
 	cards                  []*Card
+	offeringRates          OfferingRatesTable
+	rarePackCrownExclusive ExpansionCardNumber
 	offerings              iter.Seq[*BoosterCardOffering]
-	regularPack1To3List    *offeringProbabilityList
-	regularPack4List       *offeringProbabilityList
-	regularPack5List       *offeringProbabilityList
-	rarePackList           *offeringProbabilityList
+	audit                  *BoosterAudit
+	regularPack1To3List    cardSampler
+	regularPack4List       cardSampler
+	regularPack5List       cardSampler
+	rarePackList           cardSampler
 	regularPackRate        float64
 	regularPackPlusOneRate float64
 	rarePackRate           float64
@@ -145,18 +157,14 @@ func NewBooster(
 	regularPackRate float64,
 	regularPackPlusOneRate float64,
 	rarePackRate float64,
-) *Booster {
+	options *BoosterOptions,
+) (*Booster, error) {
 	totalPackRate := regularPackRate + regularPackPlusOneRate + rarePackRate
 	if totalPackRate != 1.0 {
-		panic(fmt.Sprintf("Total pack chance doesn't equal 1 for %s - %f, %f, %f = %f", name, regularPackRate, regularPackPlusOneRate, rarePackRate, totalPackRate))
+		return nil, fmt.Errorf("total pack chance doesn't equal 1 for %s - %f, %f, %f = %f", name, regularPackRate, regularPackPlusOneRate, rarePackRate, totalPackRate)
 	}
 
 	offerings := make([]*BoosterCardOffering, len(cards))
-	regularPack1To3List := offeringProbabilityList{}
-	regularPack4List := offeringProbabilityList{}
-	regularPack5List := offeringProbabilityList{}
-	rarePackList := offeringProbabilityList{}
-
 	cardsByRarity := make(map[*Rarity]uint16)
 
 	for _, c := range cards {
@@ -166,8 +174,7 @@ func NewBooster(
 	for i, c := range cards {
 		offeringRef, offeringRefExists := offeringRates[c.Rarity()]
 		if !offeringRefExists {
-			m, _ := fmt.Printf("Offering rate not found for %v %v", name, c.Rarity().value)
-			panic(m)
+			return nil, fmt.Errorf("offering rate not found for %v %v", name, c.Rarity().value)
 		}
 
 		rareCardOffering := 0.0
@@ -185,41 +192,91 @@ func NewBooster(
 			fifthCardOffering:  offeringRef.fifthCardOffering / numOfRarity,
 			rareCardOffering:   rareCardOffering / numOfRarity,
 		}
+	}
+
+	// Audit the published numbers before any policy adjusts them
+	audit := AuditOfferings(name, offerings)
+	pErr := applyOfferingDriftPolicy(options.OfferingDriftPolicy(), audit, offerings)
+	if pErr != nil {
+		return nil, pErr
+	}
 
-		regularPack1To3List.append(c, offeringRef.first3CardOffering)
-		regularPack4List.append(c, offeringRef.fourthCardOffering)
-		regularPack5List.append(c, offeringRef.fifthCardOffering)
-		rarePackList.append(c, offeringRef.rareOffering)
+	// Samplers are built from the same per card offerings used for
+	// instance probabilities so the two always agree
+	slotProbabilities := make(map[OfferingSlot][]float64, len(OrderedOfferingSlots))
+	for _, s := range OrderedOfferingSlots {
+		probabilities := make([]float64, len(offerings))
+		for i, o := range offerings {
+			probabilities[i] = s.offering(o)
+		}
+		slotProbabilities[s] = probabilities
 	}
+	method := options.SamplingMethod()
 
 	return &Booster{
 		name:                   name,
 		cards:                  cards,
+		offeringRates:          maps.Clone(offeringRates),
+		rarePackCrownExclusive: rarePackCrownExclusiveExpansionNumber,
 		offerings:              slices.Values(offerings),
-		regularPack1To3List:    &regularPack1To3List,
-		regularPack4List:       &regularPack4List,
-		regularPack5List:       &regularPack5List,
-		rarePackList:           &rarePackList,
+		audit:                  audit,
+		regularPack1To3List:    newCardSampler(method, cards, slotProbabilities[OfferingSlotFirst3]),
+		regularPack4List:       newCardSampler(method, cards, slotProbabilities[OfferingSlotFourth]),
+		regularPack5List:       newCardSampler(method, cards, slotProbabilities[OfferingSlotFifth]),
+		rarePackList:           newCardSampler(method, cards, slotProbabilities[OfferingSlotRare]),
 		regularPackRate:        regularPackRate,
 		regularPackPlusOneRate: regularPackPlusOneRate,
 		rarePackRate:           rarePackRate,
-	}
+	}, nil
 }
 
 func (b *Booster) Name() string {
 	return b.name
 }
 
+// Cards in the booster, in the order given to NewBooster.
+func (b *Booster) Cards() iter.Seq[*Card] {
+	return slices.Values(b.cards)
+}
+
+// The published per rarity offering rates the booster was created with.
+func (b *Booster) OfferingRates() OfferingRatesTable {
+	return maps.Clone(b.offeringRates)
+}
+
+// The only crown card in rare packs.
+func (b *Booster) RarePackCrownExclusiveExpansionNumber() ExpansionCardNumber {
+	return b.rarePackCrownExclusive
+}
+
+func (b *Booster) RegularPackRate() float64 {
+	return b.regularPackRate
+}
+
+func (b *Booster) RegularPackPlusOneRate() float64 {
+	return b.regularPackPlusOneRate
+}
+
+func (b *Booster) RarePackRate() float64 {
+	return b.rarePackRate
+}
+
 func (b *Booster) Offerings() iter.Seq[*BoosterCardOffering] {
 	return b.offerings
 }
 
-func (b *Booster) GetInstanceProbabilityForMissing(missing []*Card) float64 {
+// Audit of the offering rates as published, before the drift policy was
+// applied.
+func (b *Booster) Audit() *BoosterAudit {
+	return b.audit
+}
+
+func (b *Booster) GetInstanceProbabilityForMissing(missing *CardSet) float64 {
 	// TODO: Take into account regular+1 pack
 	totalRegularPackOffering := 0.0
 	totalRarePackOffering := 0.0
 	for o := range b.Offerings() {
-		if slices.Contains(missing, o.Card()) {
+		if missing.Contains(o.Card()) {
 			totalRegularPackOffering += o.RegularPackOffering()
 			totalRarePackOffering += o.RarePackOffering()
 		}
@@ -227,6 +284,67 @@ func (b *Booster) GetInstanceProbabilityForMissing(missing []*Card) float64 {
 	return totalRegularPackOffering*b.regularPackRate + totalRarePackOffering*b.rarePackRate
 }
 
+// Relative value of obtaining each card by number, cards without a weight are
+// worth nothing.
+type CardWeights map[ExpansionCardNumber]float64
+
+// Like GetInstanceProbabilityForMissing, but each missing card's chance is
+// multiplied by its weight.
+func (b *Booster) GetInstanceWeightedValueForMissing(missing *CardSet, weights CardWeights) float64 {
+	// TODO: Take into account regular+1 pack
+	totalRegularPackValue := 0.0
+	totalRarePackValue := 0.0
+	for o := range b.Offerings() {
+		if !missing.Contains(o.Card()) {
+			continue
+		}
+		w := weights[o.card.number]
+		totalRegularPackValue += w * o.RegularPackOffering()
+		totalRarePackValue += w * o.RarePackOffering()
+	}
+	return totalRegularPackValue*b.regularPackRate + totalRarePackValue*b.rarePackRate
+}
+
+// Chance a single opened pack doesn't contain the card.
+func (b *Booster) ProbabilityNotInInstance(card *Card) float64 {
+	for o := range b.Offerings() {
+		if o.card.number != card.number {
+			continue
+		}
+
+		notFirst3 := 1 - o.first3CardOffering/100
+		notFourth := 1 - o.fourthCardOffering/100
+		notFifth := 1 - o.fifthCardOffering/100
+		notRare := 1 - o.rareCardOffering/100
+		notInRegular := notFirst3 * notFirst3 * notFirst3 * notFourth * notFifth
+		// Matches CreateRandomInstance, the 6th card is drawn like the 5th
+		notInRegularPlusOne := notInRegular * notFifth
+		notInRare := notRare * notRare * notRare * notRare * notRare
+		return b.regularPackRate*notInRegular +
+			b.regularPackPlusOneRate*notInRegularPlusOne +
+			b.rarePackRate*notInRare
+	}
+	return 1
+}
+
+// Expected copies of the card in a single opened pack.
+func (b *Booster) ExpectedCopiesInInstance(card *Card) float64 {
+	for o := range b.Offerings() {
+		if o.card.number != card.number {
+			continue
+		}
+
+		inRegular := o.RegularPackOffering() / 100
+		// Matches CreateRandomInstance, the 6th card is drawn like the 5th
+		inRegularPlusOne := inRegular + o.fifthCardOffering/100
+		inRare := o.RarePackOffering() / 100
+		return b.regularPackRate*inRegular +
+			b.regularPackPlusOneRate*inRegularPlusOne +
+			b.rarePackRate*inRare
+	}
+	return 0
+}
+
 func (b *Booster) CreateRandomInstance(randomGenerator *rand.Rand) *BoosterInstance {
 	// Rare pack
 	probabilityNum := randomGenerator.Float64()
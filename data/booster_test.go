@@ -3,7 +3,7 @@ package data
 import "testing"
 
 func TestNewBoosterOfferings(t *testing.T) {
-	booster := NewBooster(
+	booster, err := NewBooster(
 		"Test booster",
 		[]*Card{
 			{
@@ -27,7 +27,11 @@ func TestNewBoosterOfferings(t *testing.T) {
 		0.9995,
 		0,
 		0.0005,
+		NewBoosterOptions(OfferingDriftAsIs, SamplingAlias),
 	)
+	if err != nil {
+		t.Fatalf("NewBooster returned error %v", err)
+	}
 
 	offeringsSeq := booster.Offerings()
 	offerings := make([]*BoosterCardOffering, 0)
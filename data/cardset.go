@@ -0,0 +1,133 @@
+package data
+
+import (
+	"iter"
+	"math/bits"
+)
+
+// A set of cards within a single expansion, stored as a bitset indexed by
+// card number. Card numbers are dense within an expansion so this stays a
+// handful of words, and different *Card instances for the same number (e.g.
+// a card shared by two boosters) are treated as the same card.
+type CardSet struct {
+	words []uint64
+}
+
+func NewCardSet(cards ...*Card) *CardSet {
+	s := &CardSet{}
+	for _, c := range cards {
+		s.Add(c)
+	}
+	return s
+}
+
+func (s *CardSet) grow(number ExpansionCardNumber) {
+	needed := int(number)/64 + 1
+	if needed > len(s.words) {
+		s.words = append(s.words, make([]uint64, needed-len(s.words))...)
+	}
+}
+
+func (s *CardSet) Add(card *Card) {
+	s.AddNumber(card.number)
+}
+
+func (s *CardSet) AddNumber(number ExpansionCardNumber) {
+	s.grow(number)
+	s.words[number/64] |= 1 << (number % 64)
+}
+
+// Returns whether the card was in the set.
+func (s *CardSet) Remove(card *Card) bool {
+	return s.RemoveNumber(card.number)
+}
+
+func (s *CardSet) RemoveNumber(number ExpansionCardNumber) bool {
+	w := int(number / 64)
+	if w >= len(s.words) {
+		return false
+	}
+	mask := uint64(1) << (number % 64)
+	present := s.words[w]&mask != 0
+	s.words[w] &^= mask
+	return present
+}
+
+func (s *CardSet) Contains(card *Card) bool {
+	return s.ContainsNumber(card.number)
+}
+
+func (s *CardSet) ContainsNumber(number ExpansionCardNumber) bool {
+	w := int(number / 64)
+	if w >= len(s.words) {
+		return false
+	}
+	return s.words[w]&(1<<(number%64)) != 0
+}
+
+func (s *CardSet) Len() int {
+	total := 0
+	for _, w := range s.words {
+		total += bits.OnesCount64(w)
+	}
+	return total
+}
+
+func (s *CardSet) IsEmpty() bool {
+	for _, w := range s.words {
+		if w != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *CardSet) Clone() *CardSet {
+	words := make([]uint64, len(s.words))
+	copy(words, s.words)
+	return &CardSet{words: words}
+}
+
+func (s *CardSet) Union(o *CardSet) *CardSet {
+	u := s.Clone()
+	if len(o.words) > len(u.words) {
+		u.words = append(u.words, make([]uint64, len(o.words)-len(u.words))...)
+	}
+	for i, w := range o.words {
+		u.words[i] |= w
+	}
+	return u
+}
+
+func (s *CardSet) Intersection(o *CardSet) *CardSet {
+	n := min(len(s.words), len(o.words))
+	words := make([]uint64, n)
+	for i := range n {
+		words[i] = s.words[i] & o.words[i]
+	}
+	return &CardSet{words: words}
+}
+
+func (s *CardSet) Intersects(o *CardSet) bool {
+	for i := range min(len(s.words), len(o.words)) {
+		if s.words[i]&o.words[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Card numbers in the set, in ascending order.
+func (s *CardSet) Numbers() iter.Seq[ExpansionCardNumber] {
+	return func(yield func(ExpansionCardNumber) bool) {
+		for i, w := range s.words {
+			for w != 0 {
+				b := bits.TrailingZeros64(w)
+				if !yield(ExpansionCardNumber(i*64 + b)) {
+					return
+				}
+				w &= w - 1
+			}
+		}
+	}
+}
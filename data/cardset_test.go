@@ -0,0 +1,48 @@
+package data
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCardSet(t *testing.T) {
+	c1 := NewCard(NewBaseCard("Test 1", 100, 0), 1, RarityOneDiamond)
+	c70 := NewCard(NewBaseCard("Test 70", 100, 0), 70, RarityOneDiamond)
+	c200 := NewCard(NewBaseCard("Test 200", 100, 0), 200, RarityOneDiamond)
+	// Same number as c70 from another booster
+	c70Other := NewCard(NewBaseCard("Test 70", 100, 0), 70, RarityOneDiamond)
+
+	set := NewCardSet(c1, c200)
+	set.Add(c70)
+	if set.Len() != 3 {
+		t.Errorf("Set length = %d; want 3", set.Len())
+	}
+	if !set.Contains(c70Other) {
+		t.Errorf("Set contains other instance of card 70 = false; want true")
+	}
+	if !set.Remove(c70Other) {
+		t.Errorf("Set remove other instance of card 70 = false; want true")
+	}
+	if set.Remove(c70) {
+		t.Errorf("Set remove removed card 70 = true; want false")
+	}
+	numbers := slices.Collect(set.Numbers())
+	if !slices.Equal(numbers, []ExpansionCardNumber{1, 200}) {
+		t.Errorf("Set numbers = %v; want [1 200]", numbers)
+	}
+
+	clone := set.Clone()
+	clone.Remove(c1)
+	if !set.Contains(c1) {
+		t.Errorf("Removing from clone changed original")
+	}
+	if set.Union(NewCardSet(c70)).Len() != 3 {
+		t.Errorf("Union length = %d; want 3", set.Union(NewCardSet(c70)).Len())
+	}
+	if !set.Intersects(NewCardSet(c200)) || set.Intersects(NewCardSet(c70)) {
+		t.Errorf("Set intersects incorrect")
+	}
+	if !NewCardSet().IsEmpty() || set.IsEmpty() {
+		t.Errorf("Set is empty incorrect")
+	}
+}
@@ -0,0 +1,104 @@
+package data
+
+import "fmt"
+
+type CardKind uint8
+
+const (
+	CardKindUnknown CardKind = iota
+	CardKindPokemon
+	CardKindTrainer
+)
+
+func (k CardKind) String() string {
+	switch k {
+	case CardKindPokemon:
+		return "Pokémon"
+	case CardKindTrainer:
+		return "Trainer"
+	}
+	return "unknown"
+}
+
+func ParseCardKind(value string) (CardKind, error) {
+	for _, k := range []CardKind{CardKindUnknown, CardKindPokemon, CardKindTrainer} {
+		if k.String() == value {
+			return k, nil
+		}
+	}
+	return CardKindUnknown, fmt.Errorf("unknown card kind '%v'", value)
+}
+
+type Stage uint8
+
+const (
+	StageUnknown Stage = iota
+	StageBasic
+	StageOne
+	StageTwo
+)
+
+func (s Stage) String() string {
+	switch s {
+	case StageBasic:
+		return "Basic"
+	case StageOne:
+		return "Stage 1"
+	case StageTwo:
+		return "Stage 2"
+	}
+	return "unknown"
+}
+
+func ParseStage(value string) (Stage, error) {
+	for _, s := range []Stage{StageUnknown, StageBasic, StageOne, StageTwo} {
+		if s.String() == value {
+			return s, nil
+		}
+	}
+	return StageUnknown, fmt.Errorf("unknown stage '%v'", value)
+}
+
+type EnergyType string
+
+const (
+	EnergyTypeUnknown   EnergyType = ""
+	EnergyTypeGrass     EnergyType = "grass"
+	EnergyTypeFire      EnergyType = "fire"
+	EnergyTypeWater     EnergyType = "water"
+	EnergyTypeLightning EnergyType = "lightning"
+	EnergyTypePsychic   EnergyType = "psychic"
+	EnergyTypeFighting  EnergyType = "fighting"
+	EnergyTypeDarkness  EnergyType = "darkness"
+	EnergyTypeMetal     EnergyType = "metal"
+	EnergyTypeDragon    EnergyType = "dragon"
+	EnergyTypeColorless EnergyType = "colorless"
+)
+
+var EnergyTypes = []EnergyType{
+	EnergyTypeGrass,
+	EnergyTypeFire,
+	EnergyTypeWater,
+	EnergyTypeLightning,
+	EnergyTypePsychic,
+	EnergyTypeFighting,
+	EnergyTypeDarkness,
+	EnergyTypeMetal,
+	EnergyTypeDragon,
+	EnergyTypeColorless,
+}
+
+// Whether energy of this type can be generated in a deck's energy zone, as
+// opposed to types only Pokémon have.
+func (t EnergyType) IsZoneEnergy() bool {
+	return t != EnergyTypeUnknown && t != EnergyTypeDragon && t != EnergyTypeColorless
+}
+
+func ParseEnergyType(value string) (EnergyType, error) {
+	for _, t := range EnergyTypes {
+		if string(t) == value {
+			return t, nil
+		}
+	}
+	return EnergyTypeUnknown, fmt.Errorf("unknown energy type '%v'", value)
+}
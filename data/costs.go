@@ -0,0 +1,277 @@
+package data
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"slices"
+	"strings"
+)
+
+// A Poké Gold purchase.
+type PokeGoldBundle struct {
+	pokeGold uint
+	price    float64
+}
+
+func NewPokeGoldBundle(pokeGold uint, price float64) *PokeGoldBundle {
+	return &PokeGoldBundle{pokeGold: pokeGold, price: price}
+}
+
+func (b *PokeGoldBundle) PokeGold() uint {
+	return b.pokeGold
+}
+
+func (b *PokeGoldBundle) Price() float64 {
+	return b.price
+}
+
+// A subscription giving extra packs each day.
+type PremiumPass struct {
+	price       float64
+	days        uint
+	packsPerDay float64
+}
+
+func NewPremiumPass(price float64, days uint, packsPerDay float64) *PremiumPass {
+	return &PremiumPass{price: price, days: days, packsPerDay: packsPerDay}
+}
+
+// Price of each period of the pass.
+func (p *PremiumPass) Price() float64 {
+	return p.price
+}
+
+// Days in each period of the pass.
+func (p *PremiumPass) Days() uint {
+	return p.days
+}
+
+func (p *PremiumPass) PacksPerDay() float64 {
+	return p.packsPerDay
+}
+
+// What opening packs costs in in-game currencies and money.
+type CostModel struct {
+	currency           string
+	hourglassesPerPack uint
+	pokeGoldPerPack    uint
+	freePacksPerDay    float64
+	bundles            []*PokeGoldBundle
+	premiumPass        *PremiumPass
+}
+
+func NewCostModel(
+	currency string,
+	hourglassesPerPack uint,
+	pokeGoldPerPack uint,
+	freePacksPerDay float64,
+	bundles []*PokeGoldBundle,
+	premiumPass *PremiumPass,
+) (*CostModel, error) {
+	if pokeGoldPerPack == 0 {
+		return nil, errors.New("packs need to cost Poké Gold")
+	}
+	if len(bundles) == 0 {
+		return nil, errors.New("no Poké Gold bundles")
+	}
+	for _, b := range bundles {
+		if b.pokeGold == 0 {
+			return nil, errors.New("Poké Gold bundles need Poké Gold")
+		}
+	}
+	return &CostModel{
+		currency:           currency,
+		hourglassesPerPack: hourglassesPerPack,
+		pokeGoldPerPack:    pokeGoldPerPack,
+		freePacksPerDay:    freePacksPerDay,
+		bundles:            bundles,
+		premiumPass:        premiumPass,
+	}, nil
+}
+
+func (m *CostModel) Currency() string {
+	return m.currency
+}
+
+func (m *CostModel) HourglassesPerPack() uint {
+	return m.hourglassesPerPack
+}
+
+func (m *CostModel) PokeGoldPerPack() uint {
+	return m.pokeGoldPerPack
+}
+
+// Packs opened for free each day without any hourglasses.
+func (m *CostModel) FreePacksPerDay() float64 {
+	return m.freePacksPerDay
+}
+
+func (m *CostModel) Bundles() iter.Seq[*PokeGoldBundle] {
+	return slices.Values(m.bundles)
+}
+
+// Nil when there is no premium pass.
+func (m *CostModel) PremiumPass() *PremiumPass {
+	return m.premiumPass
+}
+
+// Cheapest bundles that buy at least the Poké Gold, in bundle order.
+func (m *CostModel) CheapestBundles(pokeGold uint) []*PokeGoldBundle {
+	largest := uint(0)
+	for _, b := range m.bundles {
+		largest = max(largest, b.pokeGold)
+	}
+
+	// Cheapest way to buy exactly each amount, up to one largest bundle over
+	limit := pokeGold + largest
+	costs := make([]float64, limit+1)
+	last := make([]int, limit+1)
+	for g := uint(1); g <= limit; g++ {
+		costs[g] = math.Inf(1)
+		for i, b := range m.bundles {
+			if b.pokeGold <= g && costs[g-b.pokeGold]+b.price < costs[g] {
+				costs[g] = costs[g-b.pokeGold] + b.price
+				last[g] = i
+			}
+		}
+	}
+
+	best := pokeGold
+	for g := pokeGold; g <= limit; g++ {
+		if costs[g] < costs[best] {
+			best = g
+		}
+	}
+
+	counts := make([]int, len(m.bundles))
+	for g := best; g > 0; g -= m.bundles[last[g]].pokeGold {
+		counts[last[g]]++
+	}
+	var bundles []*PokeGoldBundle
+	for i, b := range m.bundles {
+		for range counts[i] {
+			bundles = append(bundles, b)
+		}
+	}
+	return bundles
+}
+
+// Cost of opening packs within the days, after the free packs of those days
+// and, if chosen, the premium pass. The pass only gives packs over days, so
+// isn't bought when days is 0.
+func (m *CostModel) Cost(packs uint64, days uint, withPremiumPass bool) *Cost {
+	cost := &Cost{packs: packs}
+	freePacks := m.freePacksPerDay * float64(days)
+	if withPremiumPass && m.premiumPass != nil && days > 0 {
+		freePacks += m.premiumPass.packsPerDay * float64(days)
+		if m.premiumPass.days > 0 {
+			periods := (days + m.premiumPass.days - 1) / m.premiumPass.days
+			cost.price += float64(periods) * m.premiumPass.price
+		}
+	}
+	cost.freePacks = min(packs, uint64(freePacks))
+
+	cost.boughtPacks = packs - cost.freePacks
+	cost.hourglasses = cost.boughtPacks * uint64(m.hourglassesPerPack)
+	cost.pokeGold = cost.boughtPacks * uint64(m.pokeGoldPerPack)
+	for _, b := range m.CheapestBundles(uint(cost.pokeGold)) {
+		cost.price += b.price
+	}
+	return cost
+}
+
+// Packs priced by a CostModel. Packs beyond the free ones cost either
+// hourglasses or Poké Gold, the price is for buying all of the Poké Gold.
+type Cost struct {
+	packs       uint64
+	freePacks   uint64
+	boughtPacks uint64
+	hourglasses uint64
+	pokeGold    uint64
+	price       float64
+}
+
+func (c *Cost) Packs() uint64 {
+	return c.packs
+}
+
+func (c *Cost) FreePacks() uint64 {
+	return c.freePacks
+}
+
+func (c *Cost) BoughtPacks() uint64 {
+	return c.boughtPacks
+}
+
+func (c *Cost) Hourglasses() uint64 {
+	return c.hourglasses
+}
+
+func (c *Cost) PokeGold() uint64 {
+	return c.pokeGold
+}
+
+func (c *Cost) Price() float64 {
+	return c.price
+}
+
+type serialisedBundle struct {
+	PokeGold uint    `json:"pokeGold"`
+	Price    float64 `json:"price"`
+}
+
+type serialisedPremiumPass struct {
+	Price       float64 `json:"price"`
+	Days        uint    `json:"days"`
+	PacksPerDay float64 `json:"packsPerDay"`
+}
+
+type serialisedCostModel struct {
+	Currency           string                 `json:"currency"`
+	HourglassesPerPack uint                   `json:"hourglassesPerPack"`
+	PokeGoldPerPack    uint                   `json:"pokeGoldPerPack"`
+	FreePacksPerDay    float64                `json:"freePacksPerDay"`
+	Bundles            []*serialisedBundle    `json:"bundles"`
+	PremiumPass        *serialisedPremiumPass `json:"premiumPass"`
+}
+
+func ReadCostModel(r io.Reader) (*CostModel, error) {
+	var serialised serialisedCostModel
+	if dErr := json.NewDecoder(r).Decode(&serialised); dErr != nil {
+		return nil, fmt.Errorf("reading cost model: %w", dErr)
+	}
+
+	bundles := make([]*PokeGoldBundle, len(serialised.Bundles))
+	for i, b := range serialised.Bundles {
+		bundles[i] = NewPokeGoldBundle(b.PokeGold, b.Price)
+	}
+	var premiumPass *PremiumPass
+	if p := serialised.PremiumPass; p != nil {
+		premiumPass = NewPremiumPass(p.Price, p.Days, p.PacksPerDay)
+	}
+	return NewCostModel(
+		serialised.Currency,
+		serialised.HourglassesPerPack,
+		serialised.PokeGoldPerPack,
+		serialised.FreePacksPerDay,
+		bundles,
+		premiumPass,
+	)
+}
+
+//go:embed costs.json
+var defaultCosts string
+
+// Approximate in-game prices, override with ReadCostModel.
+func DefaultCostModel() *CostModel {
+	model, err := ReadCostModel(strings.NewReader(defaultCosts))
+	if err != nil {
+		panic(err)
+	}
+	return model
+}
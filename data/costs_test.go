@@ -0,0 +1,46 @@
+package data
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCostModel(t *testing.T) {
+	model, err := NewCostModel(
+		"USD",
+		12,
+		6,
+		2,
+		[]*PokeGoldBundle{NewPokeGoldBundle(1, 1), NewPokeGoldBundle(10, 8)},
+		NewPremiumPass(10, 30, 1),
+	)
+	if err != nil {
+		t.Fatalf("NewCostModel returned error %v", err)
+	}
+
+	// Buying 10 is cheaper than 9 singles
+	if bundles := model.CheapestBundles(9); len(bundles) != 1 || bundles[0].PokeGold() != 10 {
+		t.Errorf("CheapestBundles(9) = %v bundles; want one of 10", len(bundles))
+	}
+
+	cost := model.Cost(30, 10, false)
+	if cost.FreePacks() != 20 || cost.Hourglasses() != 120 || cost.PokeGold() != 60 {
+		t.Errorf(
+			"Cost free, hourglasses, Poké Gold = %v, %v, %v; want 20, 120, 60",
+			cost.FreePacks(),
+			cost.Hourglasses(),
+			cost.PokeGold(),
+		)
+	}
+	if math.Abs(cost.Price()-48) > 1e-9 {
+		t.Errorf("Cost price = %v; want 48", cost.Price())
+	}
+
+	withPass := model.Cost(30, 10, true)
+	if withPass.FreePacks() != 30 || math.Abs(withPass.Price()-10) > 1e-9 {
+		t.Errorf("Cost with pass free, price = %v, %v; want 30, 10", withPass.FreePacks(), withPass.Price())
+	}
+	if now, withPassNow := model.Cost(30, 0, false), model.Cost(30, 0, true); withPassNow.Price() != now.Price() {
+		t.Errorf("Cost with pass over 0 days price = %v; want %v as the pass isn't bought", withPassNow.Price(), now.Price())
+	}
+}
@@ -16,6 +16,9 @@ type Expansion struct {
 	boosters            iter.Seq[*Booster]
 	numBoosters         uint16
 	cards               iter.Seq[*Card]
+	cardsByNumber       []*Card
+	allCards            *CardSet
+	nonSecretCards      *CardSet
 	totalNonSecretCards uint16
 	totalSecretCards    uint16
 }
@@ -27,9 +30,11 @@ func NewExpansion(
 	boosters []*Booster,
 ) *Expansion {
 	var cards []*Card
+	allCards := NewCardSet()
 	for _, b := range boosters {
 		for _, c := range b.cards {
-			if !slices.ContainsFunc(cards, func(c2 *Card) bool { return c2.number == c.number }) {
+			if !allCards.Contains(c) {
+				allCards.Add(c)
 				cards = append(cards, c)
 			}
 		}
@@ -38,10 +43,19 @@ func NewExpansion(
 		return cards[i].Number() < cards[j].Number()
 	})
 
+	var cardsByNumber []*Card
+	if len(cards) > 0 {
+		// Numbers can have gaps, e.g. promo cards not in any booster
+		cardsByNumber = make([]*Card, cards[len(cards)-1].number+1)
+	}
 	var totalSecretCards uint16 = 0
+	nonSecretCards := NewCardSet()
 	for _, c := range cards {
+		cardsByNumber[c.number] = c
 		if c.rarity.isSecret {
 			totalSecretCards += 1
+		} else {
+			nonSecretCards.Add(c)
 		}
 	}
 
@@ -52,6 +66,9 @@ func NewExpansion(
 		boosters:            slices.Values(boosters),
 		numBoosters:         uint16(len(boosters)),
 		cards:               slices.Values(cards),
+		cardsByNumber:       cardsByNumber,
+		allCards:            allCards,
+		nonSecretCards:      nonSecretCards,
 		totalSecretCards:    totalSecretCards,
 		totalNonSecretCards: uint16(len(cards)) - totalSecretCards,
 	}
@@ -65,6 +82,11 @@ func (e *Expansion) Name() string {
 	return e.name
 }
 
+// Set code used in deck lists, e.g. A1.
+func (e *Expansion) Code() string {
+	return e.code
+}
+
 func (e *Expansion) HasShiny() bool {
 	for c := range e.cards {
 		if c.Rarity().IsShiny() {
@@ -94,21 +116,49 @@ func (e *Expansion) TotalCards() uint16 {
 	return e.totalNonSecretCards + e.totalSecretCards
 }
 
-// TODO this can be more efficient with an array. Need to sort out
-// special case mew card first though.
 func (e *Expansion) GetCardByNumber(number ExpansionCardNumber) (*Card, error) {
-	for c := range e.cards {
-		if c.number == number {
-			return c, nil
-		}
+	if int(number) < len(e.cardsByNumber) && e.cardsByNumber[number] != nil {
+		return e.cardsByNumber[number], nil
 	}
 	return nil, fmt.Errorf("no card with number %v", number)
 }
 
+// A new set containing every card in the expansion.
+func (e *Expansion) AllCards() *CardSet {
+	return e.allCards.Clone()
+}
+
+// A new set containing every non secret card in the expansion.
+func (e *Expansion) NonSecretCards() *CardSet {
+	return e.nonSecretCards.Clone()
+}
+
+// The expansion's cards that are in the set, in number order.
+func (e *Expansion) CardsIn(set *CardSet) iter.Seq[*Card] {
+	return func(yield func(*Card) bool) {
+		for n := range set.Numbers() {
+			if int(n) >= len(e.cardsByNumber) {
+				return
+			}
+			c := e.cardsByNumber[n]
+			if c == nil {
+				continue
+			}
+			if !yield(c) {
+				return
+			}
+		}
+	}
+}
+
+func (e *Expansion) HasAnyNonSecret(set *CardSet) bool {
+	return set.Intersects(e.nonSecretCards)
+}
+
 func (e *Expansion) GetHighestOfferingBoosterForMissingCards(
-	missingCards []*Card,
+	missingCards *CardSet,
 ) (*Booster, error) {
-	if len(missingCards) == 0 {
+	if missingCards.IsEmpty() {
 		return nil, fmt.Errorf("no missing card numbers provided")
 	}
 
@@ -134,3 +184,30 @@ func (e *Expansion) GetHighestOfferingBoosterForMissingCards(
 
 	return bestBooster, nil
 }
+
+// Like GetHighestOfferingBoosterForMissingCards, scoring boosters with
+// Booster.GetInstanceWeightedValueForMissing.
+func (e *Expansion) GetHighestWeightedBoosterForMissingCards(
+	missingCards *CardSet,
+	weights CardWeights,
+) (*Booster, error) {
+	if missingCards.IsEmpty() {
+		return nil, fmt.Errorf("no missing card numbers provided")
+	}
+
+	var bestBooster *Booster
+	var bestBoosterValue = -1.0
+	for b := range e.Boosters() {
+		boosterValue := b.GetInstanceWeightedValueForMissing(missingCards, weights)
+		if boosterValue > bestBoosterValue {
+			bestBoosterValue = boosterValue
+			bestBooster = b
+		}
+	}
+
+	if bestBoosterValue <= 0.0 {
+		return nil, fmt.Errorf("no booster offering any weighted card number")
+	}
+
+	return bestBooster, nil
+}
@@ -0,0 +1,3 @@
+package data
+
+var AllSamplingMethods = allSamplingMethods
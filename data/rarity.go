@@ -0,0 +1,220 @@
+package data
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+type Rarity struct {
+	order              uint8
+	name               string
+	isSecret           bool
+	value              string
+	packPointsToObtain uint16
+	// Serebii's image names for the rarity, without extensions
+	imageNames  []string
+	isTradeable bool
+}
+
+const RarityDiamondChar = '♢'
+const RarityStarChar = '☆'
+const RarityShinyChar = '✵'
+const RarityCrownChar = '♕'
+
+// Position in the registry, from most to least common.
+func (r *Rarity) Order() uint8 {
+	return r.order
+}
+
+// Identifies the rarity in data files, e.g. two-diamond.
+func (r *Rarity) Name() string {
+	return r.name
+}
+
+func (r *Rarity) PackPointsToObtain() uint16 {
+	return r.packPointsToObtain
+}
+
+// The rarity's repeated character, e.g. ♢ for ♢♢.
+func (r *Rarity) Symbol() rune {
+	symbol, _ := utf8.DecodeRuneInString(r.value)
+	return symbol
+}
+
+func (r *Rarity) IsStar() bool {
+	return strings.ContainsRune(r.value, RarityStarChar)
+}
+
+func (r *Rarity) IsCrown() bool {
+	return strings.ContainsRune(r.value, RarityCrownChar)
+}
+
+func (r *Rarity) IsShiny() bool {
+	return strings.ContainsRune(r.value, RarityShinyChar)
+}
+
+func (r *Rarity) IsSecret() bool {
+	return r.isSecret
+}
+
+// Whether cards of the rarity can be traded.
+func (r *Rarity) IsTradeable() bool {
+	return r.isTradeable
+}
+
+func (r *Rarity) ImageNames() iter.Seq[string] {
+	return slices.Values(r.imageNames)
+}
+
+func (r *Rarity) String() string {
+	return r.value
+}
+
+type serialisedRarity struct {
+	Name       string   `json:"name"`
+	Symbol     string   `json:"symbol"`
+	Secret     bool     `json:"secret"`
+	PackPoints uint16   `json:"packPoints"`
+	Images     []string `json:"images"`
+	Tradeable  bool     `json:"tradeable"`
+}
+
+type serialisedRarities struct {
+	Rarities []*serialisedRarity `json:"rarities"`
+}
+
+// Every known rarity, in order.
+type RarityRegistry struct {
+	rarities []*Rarity
+}
+
+func (r *RarityRegistry) All() iter.Seq[*Rarity] {
+	return slices.Values(r.rarities)
+}
+
+func (r *RarityRegistry) ByName(name string) (*Rarity, error) {
+	for _, rarity := range r.rarities {
+		if rarity.name == name {
+			return rarity, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown rarity name '%v'", name)
+}
+
+// Finds the rarity with the symbols, e.g. ♢♢.
+func (r *RarityRegistry) BySymbol(symbol string) (*Rarity, error) {
+	for _, rarity := range r.rarities {
+		if rarity.value == symbol {
+			return rarity, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown rarity '%v'", symbol)
+}
+
+func (r *RarityRegistry) ByImageName(imageName string) (*Rarity, bool) {
+	for _, rarity := range r.rarities {
+		if slices.Contains(rarity.imageNames, imageName) {
+			return rarity, true
+		}
+	}
+	return nil, false
+}
+
+func (r *RarityRegistry) mustGet(name string) *Rarity {
+	rarity, err := r.ByName(name)
+	if err != nil {
+		panic(err)
+	}
+	return rarity
+}
+
+// Adds the rarities defined in the JSON, in order after the known ones.
+// Rarities with a known name are redefined in place, keeping their order.
+func (r *RarityRegistry) Load(reader io.Reader) error {
+	var serialised serialisedRarities
+	if dErr := json.NewDecoder(reader).Decode(&serialised); dErr != nil {
+		return fmt.Errorf("reading rarities: %w", dErr)
+	}
+
+	updated := slices.Clone(r.rarities)
+	for _, sr := range serialised.Rarities {
+		if sr.Name == "" || sr.Symbol == "" {
+			return errors.New("rarities need a name and symbol")
+		}
+		rarity := &Rarity{
+			name:               sr.Name,
+			isSecret:           sr.Secret,
+			value:              sr.Symbol,
+			packPointsToObtain: sr.PackPoints,
+			imageNames:         sr.Images,
+			isTradeable:        sr.Tradeable,
+		}
+		i := slices.IndexFunc(updated, func(existing *Rarity) bool {
+			return existing.name == sr.Name
+		})
+		if i == -1 {
+			rarity.order = uint8(len(updated))
+			updated = append(updated, rarity)
+		} else {
+			rarity.order = updated[i].order
+			updated[i] = rarity
+		}
+	}
+
+	for i, r1 := range updated {
+		for _, r2 := range updated[i+1:] {
+			if r1.value == r2.value {
+				return fmt.Errorf("rarities %v and %v have the same symbol %v", r1.name, r2.name, r1.value)
+			}
+			for _, image := range r1.imageNames {
+				if slices.Contains(r2.imageNames, image) {
+					return fmt.Errorf("rarities %v and %v have the same image %v", r1.name, r2.name, image)
+				}
+			}
+		}
+	}
+
+	// Redefine known rarities in place so cards keep theirs
+	for i, rarity := range updated {
+		if i < len(r.rarities) {
+			*r.rarities[i] = *rarity
+			updated[i] = r.rarities[i]
+		}
+	}
+	r.rarities = updated
+	return nil
+}
+
+//go:embed rarities.json
+var defaultRarities string
+
+func newDefaultRarityRegistry() *RarityRegistry {
+	registry := &RarityRegistry{}
+	if err := registry.Load(strings.NewReader(defaultRarities)); err != nil {
+		panic(err)
+	}
+	return registry
+}
+
+// The registry every card's rarity comes from, extended with Rarities.Load.
+var Rarities = newDefaultRarityRegistry()
+
+var (
+	RarityOneDiamond   = Rarities.mustGet("one-diamond")
+	RarityTwoDiamond   = Rarities.mustGet("two-diamond")
+	RarityThreeDiamond = Rarities.mustGet("three-diamond")
+	RarityFourDiamond  = Rarities.mustGet("four-diamond")
+	RarityOneStar      = Rarities.mustGet("one-star")
+	RarityTwoStar      = Rarities.mustGet("two-star")
+	RarityThreeStar    = Rarities.mustGet("three-star")
+	RarityOneShiny     = Rarities.mustGet("one-shiny")
+	RarityTwoShiny     = Rarities.mustGet("two-shiny")
+	RarityCrown        = Rarities.mustGet("crown")
+)
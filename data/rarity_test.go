@@ -0,0 +1,38 @@
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRarityRegistryLoad(t *testing.T) {
+	registry := newDefaultRarityRegistry()
+	twoDiamond, _ := registry.ByName("two-diamond")
+
+	err := registry.Load(strings.NewReader(`{"rarities": [
+		{"name": "two-diamond", "symbol": "♢♢", "packPoints": 60, "images": ["diamond2"], "tradeable": true},
+		{"name": "three-shiny", "symbol": "✵✵✵", "secret": true, "packPoints": 1800, "images": ["shiny3"]}
+	]}`))
+	if err != nil {
+		t.Fatalf("Load error = %v; want nil", err)
+	}
+
+	if redefined, _ := registry.BySymbol("♢♢"); redefined != twoDiamond || redefined.PackPointsToObtain() != 60 {
+		t.Errorf("Redefined ♢♢ = %p costing %v; want %p costing 60", redefined, redefined.PackPointsToObtain(), twoDiamond)
+	}
+	added, found := registry.ByImageName("shiny3")
+	if !found || added.String() != "✵✵✵" || !added.IsSecret() || !added.IsShiny() || added.IsTradeable() {
+		t.Errorf("ByImageName(shiny3) = %v, %v; want secret untradeable ✵✵✵", added, found)
+	}
+	if added.Order() != 10 {
+		t.Errorf("Added rarity order = %v; want 10 after the built in ones", added.Order())
+	}
+
+	err = registry.Load(strings.NewReader(`{"rarities": [{"name": "other-crown", "symbol": "♕"}]}`))
+	if err == nil {
+		t.Errorf("Load with a duplicate symbol error = nil; want error")
+	}
+	if _, err := registry.ByName("other-crown"); err == nil {
+		t.Errorf("ByName after a failed load found other-crown; want error")
+	}
+}
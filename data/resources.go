@@ -0,0 +1,93 @@
+package data
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strings"
+)
+
+type serialisedResource struct {
+	Name string `json:"name"`
+	// By rarity name
+	Amounts map[string]float64 `json:"amounts"`
+}
+
+type serialisedResources struct {
+	Resources []*serialisedResource `json:"resources"`
+}
+
+// How much of each in-game resource a duplicate card converts into, by
+// rarity. Each resource is a separate conversion, e.g. trading in duplicates
+// for trade tokens.
+type ResourceTable struct {
+	names   []string
+	amounts map[string]map[*Rarity]float64
+}
+
+// Resource names in the order defined.
+func (t *ResourceTable) Names() iter.Seq[string] {
+	return slices.Values(t.names)
+}
+
+// Amount of the resource one duplicate of the rarity converts into.
+func (t *ResourceTable) Amount(resource string, rarity *Rarity) float64 {
+	return t.amounts[resource][rarity]
+}
+
+// Total of each resource the duplicates convert into.
+func (t *ResourceTable) Convert(duplicates map[*Rarity]float64) map[string]float64 {
+	totals := make(map[string]float64, len(t.names))
+	for _, name := range t.names {
+		totals[name] = 0
+		for r, d := range duplicates {
+			totals[name] += d * t.amounts[name][r]
+		}
+	}
+	return totals
+}
+
+// Reads resources with amounts by rarity name, looked up in Rarities.
+func ReadResourceTable(r io.Reader) (*ResourceTable, error) {
+	var serialised serialisedResources
+	if dErr := json.NewDecoder(r).Decode(&serialised); dErr != nil {
+		return nil, fmt.Errorf("reading resources: %w", dErr)
+	}
+
+	table := &ResourceTable{amounts: make(map[string]map[*Rarity]float64)}
+	for _, sr := range serialised.Resources {
+		if sr.Name == "" {
+			return nil, errors.New("resources need a name")
+		}
+		if _, exists := table.amounts[sr.Name]; exists {
+			return nil, fmt.Errorf("resource %v defined twice", sr.Name)
+		}
+		amounts := make(map[*Rarity]float64, len(sr.Amounts))
+		for rarityName, amount := range sr.Amounts {
+			rarity, rErr := Rarities.ByName(rarityName)
+			if rErr != nil {
+				return nil, fmt.Errorf("resource %v: %w", sr.Name, rErr)
+			}
+			amounts[rarity] = amount
+		}
+		table.names = append(table.names, sr.Name)
+		table.amounts[sr.Name] = amounts
+	}
+	return table, nil
+}
+
+//go:embed resources.json
+var defaultResources string
+
+// Approximate in-game conversions, override with ReadResourceTable.
+func DefaultResourceTable() *ResourceTable {
+	table, err := ReadResourceTable(strings.NewReader(defaultResources))
+	if err != nil {
+		panic(err)
+	}
+	return table
+}
@@ -0,0 +1,206 @@
+package data
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+)
+
+// How cards are drawn from a booster slot. Each method consumes random
+// numbers differently, so a seed only reproduces results for the same method.
+type SamplingMethod uint8
+
+const (
+	// Walker alias table, O(1) per draw
+	SamplingAlias SamplingMethod = iota
+	// Binary search over cumulative probabilities, O(log n) per draw
+	SamplingBinarySearch
+	// Linear scan over cumulative probabilities, O(n) per draw
+	SamplingLinear
+)
+
+var samplingMethodNames = map[SamplingMethod]string{
+	SamplingAlias:        "alias",
+	SamplingBinarySearch: "binary",
+	SamplingLinear:       "linear",
+}
+
+func ParseSamplingMethod(value string) (SamplingMethod, error) {
+	for m, n := range samplingMethodNames {
+		if n == value {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown sampling method '%v'", value)
+}
+
+func (m SamplingMethod) String() string {
+	return samplingMethodNames[m]
+}
+
+type cardSampler interface {
+	pickRandomCard(randomGenerator *rand.Rand) *Card
+}
+
+// Cards with a probability of 0 are never picked and are left out.
+func newCardSampler(method SamplingMethod, cards []*Card, probabilities []float64) cardSampler {
+	switch method {
+	case SamplingLinear:
+		list := &offeringProbabilityList{}
+		for i, c := range cards {
+			list.append(c, probabilities[i])
+		}
+		return list
+	case SamplingBinarySearch:
+		return newCumulativeProbabilityList(cards, probabilities)
+	case SamplingAlias:
+		return newAliasTable(cards, probabilities)
+	}
+	panic(fmt.Sprintf("unknown sampling method %d", method))
+}
+
+type cardProbabilityEntry struct {
+	cumulativeProbability float64
+	card                  *Card
+}
+
+type offeringProbabilityList struct {
+	// 100 unless the booster was created with OfferingDriftAsIs, see
+	// BoosterAudit for how far the source data drifts.
+	totalProbability float64
+	entries          []*cardProbabilityEntry
+}
+
+func (o *offeringProbabilityList) append(card *Card, probability float64) {
+	if probability == 0 {
+		return
+	}
+
+	o.totalProbability += probability
+	o.entries = append(o.entries, &cardProbabilityEntry{
+		cumulativeProbability: o.totalProbability,
+		card:                  card,
+	})
+}
+
+func (o *offeringProbabilityList) pickRandomCard(randomGenerator *rand.Rand) *Card {
+	num := randomGenerator.Float64() * o.totalProbability
+	for _, e := range o.entries {
+		if num <= e.cumulativeProbability {
+			return e.card
+		}
+	}
+	panic(fmt.Sprintf("Invalid algorithm %v num %v total", num, o.totalProbability))
+}
+
+type cumulativeProbabilityList struct {
+	cumulativeProbabilities []float64
+	cards                   []*Card
+}
+
+func newCumulativeProbabilityList(cards []*Card, probabilities []float64) *cumulativeProbabilityList {
+	list := &cumulativeProbabilityList{}
+	total := 0.0
+	for i, c := range cards {
+		if probabilities[i] == 0 {
+			continue
+		}
+		total += probabilities[i]
+		list.cumulativeProbabilities = append(list.cumulativeProbabilities, total)
+		list.cards = append(list.cards, c)
+	}
+	return list
+}
+
+func (l *cumulativeProbabilityList) pickRandomCard(randomGenerator *rand.Rand) *Card {
+	if len(l.cards) == 0 {
+		panic("No cards to pick from")
+	}
+	total := l.cumulativeProbabilities[len(l.cumulativeProbabilities)-1]
+	num := randomGenerator.Float64() * total
+	i := sort.SearchFloat64s(l.cumulativeProbabilities, num)
+	// Float64 is in [0, 1) so this only guards against rounding
+	if i == len(l.cards) {
+		i--
+	}
+	return l.cards[i]
+}
+
+// Vose's variant of the Walker alias method. Each column holds its own card
+// with chance acceptProbability, otherwise its alias.
+type aliasTable struct {
+	cards               []*Card
+	aliases             []*Card
+	acceptProbabilities []float64
+}
+
+func newAliasTable(cards []*Card, probabilities []float64) *aliasTable {
+	var nonZeroCards []*Card
+	var nonZeroProbabilities []float64
+	total := 0.0
+	for i, c := range cards {
+		if probabilities[i] == 0 {
+			continue
+		}
+		nonZeroCards = append(nonZeroCards, c)
+		nonZeroProbabilities = append(nonZeroProbabilities, probabilities[i])
+		total += probabilities[i]
+	}
+
+	n := len(nonZeroCards)
+	table := &aliasTable{
+		cards:               nonZeroCards,
+		aliases:             make([]*Card, n),
+		acceptProbabilities: make([]float64, n),
+	}
+
+	// Scale so the average column is exactly 1
+	scaled := make([]float64, n)
+	var small []int
+	var large []int
+	for i, p := range nonZeroProbabilities {
+		scaled[i] = p * float64(n) / total
+		if scaled[i] < 1.0 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	for len(small) > 0 && len(large) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+		l := large[len(large)-1]
+		large = large[:len(large)-1]
+
+		table.acceptProbabilities[s] = scaled[s]
+		table.aliases[s] = nonZeroCards[l]
+
+		scaled[l] = scaled[l] + scaled[s] - 1.0
+		if scaled[l] < 1.0 {
+			small = append(small, l)
+		} else {
+			large = append(large, l)
+		}
+	}
+	// Whatever remains is 1 give or take rounding error
+	for _, i := range large {
+		table.acceptProbabilities[i] = 1.0
+	}
+	for _, i := range small {
+		table.acceptProbabilities[i] = 1.0
+	}
+
+	return table
+}
+
+func (a *aliasTable) pickRandomCard(randomGenerator *rand.Rand) *Card {
+	if len(a.cards) == 0 {
+		panic("No cards to pick from")
+	}
+	i := randomGenerator.IntN(len(a.cards))
+	if randomGenerator.Float64() < a.acceptProbabilities[i] {
+		return a.cards[i]
+	}
+	return a.aliases[i]
+}
@@ -0,0 +1,43 @@
+package data_test
+
+import (
+	"math/rand/v2"
+	"ptcgpocket/data"
+	"ptcgpocket/internal/testexpansion"
+	"testing"
+)
+
+func TestCardSamplersReproducible(t *testing.T) {
+	for _, m := range data.AllSamplingMethods {
+		booster := testexpansion.GeneticApexShapedBooster(data.NewBoosterOptions(data.OfferingDriftRenormalise, m))
+		r1 := rand.New(rand.NewPCG(7, 7))
+		r2 := rand.New(rand.NewPCG(7, 7))
+		for range 100 {
+			i1 := booster.CreateRandomInstance(r1)
+			i2 := booster.CreateRandomInstance(r2)
+			var cards1 []*data.Card
+			for c := range i1.Cards() {
+				cards1 = append(cards1, c)
+			}
+			j := 0
+			for c := range i2.Cards() {
+				if cards1[j] != c {
+					t.Fatalf("%v sampler not reproducible for the same seed", m)
+				}
+				j++
+			}
+		}
+	}
+}
+
+func BenchmarkCreateRandomInstance(b *testing.B) {
+	for _, m := range data.AllSamplingMethods {
+		b.Run(m.String(), func(b *testing.B) {
+			booster := testexpansion.GeneticApexShapedBooster(data.NewBoosterOptions(data.OfferingDriftRenormalise, m))
+			randomGenerator := rand.New(rand.NewPCG(1, 2))
+			for b.Loop() {
+				booster.CreateRandomInstance(randomGenerator)
+			}
+		})
+	}
+}
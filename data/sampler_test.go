@@ -0,0 +1,36 @@
+package data
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+var allSamplingMethods = []SamplingMethod{SamplingAlias, SamplingBinarySearch, SamplingLinear}
+
+func TestCardSamplersMatchProbabilities(t *testing.T) {
+	cards := []*Card{
+		NewCard(NewBaseCard("Bulbasaur", 70, 1), 1, RarityOneDiamond),
+		NewCard(NewBaseCard("Ivysaur", 90, 2), 2, RarityTwoDiamond),
+		NewCard(NewBaseCard("Venusaur", 160, 3), 3, RarityThreeDiamond),
+		NewCard(NewBaseCard("Venusaur ex", 190, 3), 4, RarityFourDiamond),
+	}
+	probabilities := []float64{50.0, 0, 30.0, 20.0}
+
+	for _, m := range allSamplingMethods {
+		sampler := newCardSampler(m, cards, probabilities)
+		randomGenerator := rand.New(rand.NewPCG(1, 2))
+		counts := make(map[*Card]int)
+		const draws = 200_000
+		for range draws {
+			counts[sampler.pickRandomCard(randomGenerator)]++
+		}
+
+		for i, c := range cards {
+			got := 100.0 * float64(counts[c]) / draws
+			if math.Abs(got-probabilities[i]) > 0.5 {
+				t.Errorf("%v sampler card %v drawn %.2f%%; want %.2f%%", m, c.Number(), got, probabilities[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package deck
+
+import (
+	"cmp"
+	"iter"
+	"math"
+	"ptcgpocket/data"
+	"ptcgpocket/userdata"
+	"slices"
+)
+
+type BuildMethod uint8
+
+const (
+	// Redeem with pack points already held
+	BuildByRedeeming BuildMethod = iota
+	// Open packs for the pack points to redeem
+	BuildByEarningPoints
+	// Open packs until the copies are pulled
+	BuildByOpening
+)
+
+func (m BuildMethod) String() string {
+	switch m {
+	case BuildByRedeeming:
+		return "redeem"
+	case BuildByEarningPoints:
+		return "earn points"
+	case BuildByOpening:
+		return "open packs"
+	}
+	return "unknown"
+}
+
+// How to obtain the missing copies of a deck card.
+type BuildStep struct {
+	card     *DeckCard
+	printing *Printing
+	copies   uint8
+	method   BuildMethod
+	// Pack points to redeem every copy
+	packPoints uint
+	// Best booster to pull the printing from, nil if no booster has it
+	booster       *data.Booster
+	expectedPacks float64
+}
+
+func (s *BuildStep) Card() *DeckCard {
+	return s.card
+}
+
+func (s *BuildStep) Printing() *Printing {
+	return s.printing
+}
+
+func (s *BuildStep) Copies() uint8 {
+	return s.copies
+}
+
+func (s *BuildStep) Method() BuildMethod {
+	return s.method
+}
+
+func (s *BuildStep) PackPoints() uint {
+	return s.packPoints
+}
+
+func (s *BuildStep) Booster() (*data.Booster, bool) {
+	return s.booster, s.booster != nil
+}
+
+// Packs to open for the step's method, 0 when redeeming.
+func (s *BuildStep) ExpectedPacks() float64 {
+	return s.expectedPacks
+}
+
+type BuildPlan struct {
+	deck  *Deck
+	steps []*BuildStep
+}
+
+func (p *BuildPlan) Deck() *Deck {
+	return p.deck
+}
+
+func (p *BuildPlan) Steps() iter.Seq[*BuildStep] {
+	return slices.Values(p.steps)
+}
+
+// Whether the collection already supplies every card.
+func (p *BuildPlan) IsBuildable() bool {
+	return len(p.steps) == 0
+}
+
+// Packs to open for every step, an upper bound as a pack can help with
+// several steps.
+func (p *BuildPlan) ExpectedPacks() float64 {
+	var total float64
+	for _, s := range p.steps {
+		total += s.expectedPacks
+	}
+	return total
+}
+
+// The cheapest printing to redeem, lowest rarity first on a tie.
+func cheapestPrinting(c *DeckCard) *Printing {
+	return slices.MinFunc(c.printings, func(p1, p2 *Printing) int {
+		return cmp.Or(
+			cmp.Compare(p1.card.Rarity().PackPointsToObtain(), p2.card.Rarity().PackPointsToObtain()),
+			cmp.Compare(p1.card.Rarity().Order(), p2.card.Rarity().Order()),
+		)
+	})
+}
+
+// Chooses the cheapest way to obtain each missing copy, preferring low rarity
+// printings. Pack points held are spent on the cheapest cards first, and
+// cards they can't cover are either pulled or redeemed after earning the
+// rest of the points, whichever takes fewer packs.
+func PlanBuild(deck *Deck, userCollection *userdata.UserCollection) *BuildPlan {
+	var steps []*BuildStep
+	for _, c := range deck.cards {
+		missingCopies := c.MissingCopies(userCollection)
+		if missingCopies == 0 {
+			continue
+		}
+		p := cheapestPrinting(c)
+		step := &BuildStep{
+			card:       c,
+			printing:   p,
+			copies:     missingCopies,
+			packPoints: uint(missingCopies) * uint(p.card.Rarity().PackPointsToObtain()),
+		}
+
+		bestPullChance := 0.0
+		for b := range p.expansion.Boosters() {
+			if chance := 1 - b.ProbabilityNotInInstance(p.card); chance > bestPullChance {
+				bestPullChance = chance
+				step.booster = b
+			}
+		}
+		steps = append(steps, step)
+	}
+	slices.SortStableFunc(steps, func(s1, s2 *BuildStep) int {
+		return cmp.Compare(s1.packPoints, s2.packPoints)
+	})
+
+	pools := make(map[data.ExpansionId]*userdata.PackPointPool)
+	for _, s := range steps {
+		eId := s.printing.expansion.Id()
+		if _, pFound := pools[eId]; !pFound {
+			pools[eId] = userdata.NewPackPointPool(0)
+			if eCollection := userCollection.GetExpansionCollection(eId); eCollection != nil {
+				pools[eId] = eCollection.PackPointPool().Clone()
+			}
+		}
+		pool := pools[eId]
+
+		if s.packPoints <= uint(pool.Balance()) {
+			s.method = BuildByRedeeming
+			for range s.copies {
+				pool.Redeem(s.printing.card.Rarity())
+			}
+			continue
+		}
+
+		// Each copy is earned and redeemed in turn, as together they can cost
+		// more than the pool holds
+		packsToEarn := math.Inf(1)
+		perCopy := uint(s.printing.card.Rarity().PackPointsToObtain())
+		first, fErr := pool.PacksToEarn(perCopy)
+		rest, rErr := userdata.NewPackPointPool(0).PacksToEarn(perCopy)
+		if fErr == nil && rErr == nil {
+			packsToEarn = float64(first + uint(s.copies-1)*rest)
+		}
+		packsToPull := math.Inf(1)
+		if s.booster != nil {
+			packsToPull = float64(s.copies) / (1 - s.booster.ProbabilityNotInInstance(s.printing.card))
+		}
+		if packsToPull < packsToEarn {
+			s.method = BuildByOpening
+			s.expectedPacks = packsToPull
+			continue
+		}
+		s.method = BuildByEarningPoints
+		s.expectedPacks = packsToEarn
+		pools[eId] = userdata.NewPackPointPool(0)
+	}
+
+	return &BuildPlan{deck: deck, steps: steps}
+}
@@ -0,0 +1,162 @@
+package deck
+
+import (
+	"cmp"
+	"fmt"
+	"iter"
+	"ptcgpocket/data"
+	"slices"
+	"strings"
+)
+
+// A card as printed in a particular expansion.
+type Printing struct {
+	expansion *data.Expansion
+	card      *data.Card
+}
+
+func (p *Printing) Expansion() *data.Expansion {
+	return p.expansion
+}
+
+func (p *Printing) Card() *data.Card {
+	return p.card
+}
+
+func (p *Printing) String() string {
+	return fmt.Sprintf("%v %v %v (%v)", p.card.Name(), p.expansion.Code(), p.card.Number(), p.card.Rarity())
+}
+
+// Copies of a card in a deck, playable using any printing of its base card.
+type DeckCard struct {
+	entry *ListEntry
+	count uint8
+	// The printing named in the deck list, nil when it wasn't recognised
+	listed    *Printing
+	printings []*Printing
+}
+
+func (c *DeckCard) Entry() *ListEntry {
+	return c.entry
+}
+
+func (c *DeckCard) Name() string {
+	return c.printings[0].card.Name()
+}
+
+func (c *DeckCard) Count() uint8 {
+	return c.count
+}
+
+func (c *DeckCard) Listed() (*Printing, bool) {
+	return c.listed, c.listed != nil
+}
+
+// Every known printing, lowest rarity first.
+func (c *DeckCard) Printings() iter.Seq[*Printing] {
+	return slices.Values(c.printings)
+}
+
+// The listed printing, or the lowest rarity one when it wasn't recognised.
+func (c *DeckCard) Preferred() *Printing {
+	if c.listed != nil {
+		return c.listed
+	}
+	return c.printings[0]
+}
+
+type Deck struct {
+	name  string
+	cards []*DeckCard
+}
+
+func (d *Deck) Name() string {
+	return d.name
+}
+
+func (d *Deck) Cards() iter.Seq[*DeckCard] {
+	return slices.Values(d.cards)
+}
+
+func (d *Deck) TotalCards() uint {
+	var total uint
+	for _, c := range d.cards {
+		total += uint(c.count)
+	}
+	return total
+}
+
+func findPrintings(expansions []*data.Expansion, matches func(*data.Card) bool) []*Printing {
+	var printings []*Printing
+	for _, e := range expansions {
+		for c := range e.Cards() {
+			if matches(c) {
+				printings = append(printings, &Printing{expansion: e, card: c})
+			}
+		}
+	}
+	slices.SortStableFunc(printings, func(p1, p2 *Printing) int {
+		return cmp.Compare(p1.card.Rarity().Order(), p2.card.Rarity().Order())
+	})
+	return printings
+}
+
+// Resolves deck list entries to cards in the expansions. Entries are matched
+// by set code and number, falling back to the card name for unknown sets such
+// as promos. Entries that can't be found are left out of the deck, with an
+// error for each; a name not matching the numbered card is also reported.
+func Resolve(name string, entries []*ListEntry, expansions []*data.Expansion) (*Deck, []error) {
+	deck := &Deck{name: name}
+	var errs []error
+	for _, entry := range entries {
+		var listed *Printing
+		eIndex := slices.IndexFunc(expansions, func(e *data.Expansion) bool {
+			return strings.EqualFold(e.Code(), entry.expansionCode)
+		})
+		if eIndex != -1 {
+			if c, cErr := expansions[eIndex].GetCardByNumber(entry.number); cErr == nil {
+				listed = &Printing{expansion: expansions[eIndex], card: c}
+			}
+		}
+		if listed != nil && !strings.EqualFold(listed.card.Name(), entry.name) {
+			errs = append(errs, fmt.Errorf(
+				"line %v: '%v' is %v, using it",
+				entry.line,
+				entry,
+				listed,
+			))
+		}
+
+		var printings []*Printing
+		if listed != nil {
+			printings = findPrintings(expansions, func(c *data.Card) bool {
+				return c.Base().IsEqual(listed.card.Base())
+			})
+		} else {
+			printings = findPrintings(expansions, func(c *data.Card) bool {
+				return strings.EqualFold(c.Name(), entry.name)
+			})
+		}
+		if len(printings) == 0 {
+			errs = append(errs, fmt.Errorf("line %v: no card found for '%v'", entry.line, entry))
+			continue
+		}
+
+		// The same card listed on several lines
+		existingIndex := slices.IndexFunc(deck.cards, func(c *DeckCard) bool {
+			return c.printings[0].card.Base().IsEqual(printings[0].card.Base())
+		})
+		if existingIndex != -1 {
+			deck.cards[existingIndex].count += entry.count
+			continue
+		}
+
+		deck.cards = append(deck.cards, &DeckCard{
+			entry:     entry,
+			count:     entry.count,
+			listed:    listed,
+			printings: printings,
+		})
+	}
+	return deck, errs
+}
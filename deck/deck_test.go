@@ -0,0 +1,123 @@
+package deck
+
+import (
+	"errors"
+	"ptcgpocket/data"
+	"ptcgpocket/internal/testexpansion"
+	"ptcgpocket/userdata"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseList(t *testing.T) {
+	entries, err := ParseList(strings.NewReader(`
+Pokémon: 2
+2 Pikachu ex A1 96
+
+Trainer: 2
+2 Professor's Research P-A 7
+`))
+	if err != nil {
+		t.Fatalf("ParseList error = %v; want nil", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("ParseList entries = %v; want 2", len(entries))
+	}
+	if e := entries[1]; e.Count() != 2 || e.Name() != "Professor's Research" || e.ExpansionCode() != "P-A" || e.Number() != 7 {
+		t.Errorf("ParseList entry = %v; want 2 Professor's Research P-A 7", e)
+	}
+
+	if _, err := ParseList(strings.NewReader("Pikachu ex A1 96")); err == nil {
+		t.Errorf("ParseList without count error = nil; want error")
+	}
+}
+
+func TestResolveMissingWishlist(t *testing.T) {
+	pikachuBase := data.NewBaseCard("Pikachu ex", 120, 1)
+	pikachu := data.NewCard(pikachuBase, 96, data.RarityOneDiamond)
+	pikachuFullArt := data.NewCard(pikachuBase, 259, data.RarityFourDiamond)
+	potion := data.NewCard(data.NewBaseCard("Potion", 0, 0), 1, data.RarityOneDiamond)
+	expansion := testexpansion.New("A1", []*data.Card{pikachu, pikachuFullArt, potion})
+
+	entries, _ := ParseList(strings.NewReader("2 Pikachu ex A1 259\n2 Potion P-A 1\n1 Mewtwo A1 300"))
+	deck, errs := Resolve("test", entries, []*data.Expansion{expansion})
+	if len(errs) != 1 {
+		t.Errorf("Resolve errors = %v; want 1 for Mewtwo", errs)
+	}
+	if deck.TotalCards() != 4 {
+		t.Errorf("Deck total cards = %v; want 4", deck.TotalCards())
+	}
+
+	// Own no Pikachu or Potions
+	collection := userdata.NewUserCollection(map[data.ExpansionId]*userdata.ExpansionCollection{
+		"A1": userdata.NewExpansionCollection(expansion, data.NewCardSet(pikachu, pikachuFullArt, potion), 0),
+	})
+	wishlist := NewMissingWishlist(deck, collection)
+	cards, _ := wishlist.CardSetForExpansion("A1")
+	if !cards.Contains(pikachu) || cards.Contains(pikachuFullArt) {
+		t.Errorf("Wishlist cards = %v; want the cheapest Pikachu printing, not the listed full art", slices.Collect(cards.Numbers()))
+	}
+
+	if copies := wishlist.CopiesWanted("A1", pikachu); copies != 2 {
+		t.Errorf("Wanted copies of Pikachu = %v; want 2", copies)
+	}
+
+	// Own the regular Pikachu once, so its second copy is still wanted
+	collection = userdata.NewUserCollection(map[data.ExpansionId]*userdata.ExpansionCollection{
+		"A1": userdata.NewExpansionCollection(expansion, data.NewCardSet(pikachuFullArt, potion), 0),
+	})
+	wishlist = NewMissingWishlist(deck, collection)
+	if copies := wishlist.CopiesWanted("A1", pikachu); copies != 2 {
+		t.Errorf("Wanted copies of Pikachu owning one = %v; want 2", copies)
+	}
+	if copies := wishlist.CopiesWanted("A1", potion); copies != 2 {
+		t.Errorf("Wanted copies of Potion = %v; want 2", copies)
+	}
+	eCollection := collection.GetExpansionCollection("A1")
+	if wanted := slices.Collect(wishlist.Wanted(eCollection).Numbers()); !slices.Equal(wanted, []data.ExpansionCardNumber{1, 96}) {
+		t.Errorf("Wanted = %v; want [1 96]", wanted)
+	}
+
+	// The full art counts as owned, so only the regular printing's first copy
+	// is wanted
+	collection = userdata.NewUserCollection(map[data.ExpansionId]*userdata.ExpansionCollection{
+		"A1": userdata.NewExpansionCollection(expansion, data.NewCardSet(pikachu, potion), 0),
+	})
+	wishlist = NewMissingWishlist(deck, collection)
+	if copies := wishlist.CopiesWanted("A1", pikachu); copies != 1 {
+		t.Errorf("Wanted copies of Pikachu owning the full art = %v; want 1", copies)
+	}
+}
+
+func TestValidateAndPlanBuild(t *testing.T) {
+	pikachuBase := data.NewPokemonBaseCard("Pikachu", 60, 1, data.StageBasic, data.EnergyTypeLightning)
+	pikachu := data.NewCard(pikachuBase, 1, data.RarityOneDiamond)
+	pikachuStar := data.NewCard(pikachuBase, 200, data.RarityOneStar)
+	raichu := data.NewCard(data.NewPokemonBaseCard("Raichu", 100, 1, data.StageOne, data.EnergyTypeLightning), 2, data.RarityOneDiamond)
+	potion := data.NewCard(data.NewTrainerBaseCard("Potion"), 3, data.RarityOneDiamond)
+	expansion := testexpansion.New("A1", []*data.Card{pikachu, pikachuStar, raichu, potion})
+
+	entries, _ := ParseList(strings.NewReader("3 Pikachu A1 200\n1 Raichu A1 2\n1 Potion A1 3"))
+	deck, _ := Resolve("test", entries, []*data.Expansion{expansion})
+	errs := deck.Validate()
+	if len(errs) != 2 || !errors.Is(errs[0], ErrDeckSize) || !errors.Is(errs[1], ErrTooManyCopies) {
+		t.Errorf("Validate = %v; want deck size and too many copies", errs)
+	}
+
+	// Enough pack points to redeem one of the Pikachu printings only
+	collection := userdata.NewUserCollection(map[data.ExpansionId]*userdata.ExpansionCollection{
+		"A1": userdata.NewExpansionCollection(expansion, data.NewCardSet(pikachu, pikachuStar), 105),
+	})
+	plan := PlanBuild(deck, collection)
+	steps := slices.Collect(plan.Steps())
+	if len(steps) != 1 {
+		t.Fatalf("Plan steps = %v; want 1", len(steps))
+	}
+	if steps[0].Printing().Card() != pikachu {
+		t.Errorf("Plan printing = %v; want the ♢ Pikachu", steps[0].Printing())
+	}
+	if steps[0].Method() != BuildByRedeeming || steps[0].PackPoints() != 105 {
+		t.Errorf("Plan = %v for %v points; want redeem for 105", steps[0].Method(), steps[0].PackPoints())
+	}
+}
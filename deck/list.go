@@ -0,0 +1,90 @@
+package deck
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"ptcgpocket/data"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A line of a deck list, e.g. "2 Pikachu ex A1 96".
+type ListEntry struct {
+	line          int
+	count         uint8
+	name          string
+	expansionCode string
+	number        data.ExpansionCardNumber
+}
+
+func (e *ListEntry) Line() int {
+	return e.line
+}
+
+func (e *ListEntry) Count() uint8 {
+	return e.count
+}
+
+func (e *ListEntry) Name() string {
+	return e.name
+}
+
+func (e *ListEntry) ExpansionCode() string {
+	return e.expansionCode
+}
+
+func (e *ListEntry) Number() data.ExpansionCardNumber {
+	return e.number
+}
+
+func (e *ListEntry) String() string {
+	return fmt.Sprintf("%v %v %v %v", e.count, e.name, e.expansionCode, e.number)
+}
+
+var listEntryRegexp = regexp.MustCompile(`^(\d+)\s+(.+?)\s+([A-Za-z0-9]+(?:-[A-Za-z0-9]+)?)\s+(\d+)$`)
+
+// Section headings exported alongside the cards, e.g. "Pokémon: 12".
+var listSectionRegexp = regexp.MustCompile(`^[^\d][^:]*:\s*\d*$`)
+
+// Parses deck list text with one "<count> <name> <set code> <number>" entry
+// per line. Blank lines, section headings and lines starting with # are
+// ignored.
+func ParseList(r io.Reader) ([]*ListEntry, error) {
+	var entries []*ListEntry
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || listSectionRegexp.MatchString(line) {
+			continue
+		}
+
+		matches := listEntryRegexp.FindStringSubmatch(line)
+		if matches == nil {
+			return nil, fmt.Errorf("line %v: expected '<count> <name> <set code> <number>', got '%v'", lineNumber, line)
+		}
+		count, cErr := strconv.ParseUint(matches[1], 10, 8)
+		if cErr != nil || count == 0 {
+			return nil, fmt.Errorf("line %v: invalid count '%v'", lineNumber, matches[1])
+		}
+		number, nErr := strconv.ParseUint(matches[4], 10, 16)
+		if nErr != nil {
+			return nil, fmt.Errorf("line %v: invalid card number '%v'", lineNumber, matches[4])
+		}
+
+		entries = append(entries, &ListEntry{
+			line:          lineNumber,
+			count:         uint8(count),
+			name:          matches[2],
+			expansionCode: matches[3],
+			number:        data.ExpansionCardNumber(number),
+		})
+	}
+	if sErr := scanner.Err(); sErr != nil {
+		return nil, sErr
+	}
+	return entries, nil
+}
@@ -0,0 +1,85 @@
+package deck
+
+import (
+	"errors"
+	"fmt"
+	"ptcgpocket/data"
+	"slices"
+)
+
+const DeckSize = 20
+const MaxCopiesPerName = 2
+
+// Most energy types a deck's energy zone can generate.
+const MaxEnergyZoneTypes = 3
+
+var (
+	ErrDeckSize           = errors.New("deck must have exactly 20 cards")
+	ErrTooManyCopies      = errors.New("deck has more than 2 copies of a card")
+	ErrNoBasicPokemon     = errors.New("deck has no Basic Pokémon")
+	ErrTooManyEnergyTypes = errors.New("deck needs more than 3 energy types")
+	ErrUnknownCardDetails = errors.New("card details unknown")
+)
+
+// Energy types the deck's Pokémon use that the energy zone can generate.
+func (d *Deck) EnergyTypes() []data.EnergyType {
+	var energyTypes []data.EnergyType
+	for _, c := range d.cards {
+		t := c.printings[0].card.Base().EnergyType()
+		if t.IsZoneEnergy() && !slices.Contains(energyTypes, t) {
+			energyTypes = append(energyTypes, t)
+		}
+	}
+	return energyTypes
+}
+
+// Checks the deck against the deck building rules, returning every rule
+// broken. Rules that can't be checked because card details weren't available
+// are reported with ErrUnknownCardDetails.
+func (d *Deck) Validate() []error {
+	var errs []error
+	if total := d.TotalCards(); total != DeckSize {
+		errs = append(errs, fmt.Errorf("%w, has %v", ErrDeckSize, total))
+	}
+
+	// Different printings and base cards can share a name
+	copiesByName := make(map[string]uint)
+	var names []string
+	for _, c := range d.cards {
+		if _, seen := copiesByName[c.Name()]; !seen {
+			names = append(names, c.Name())
+		}
+		copiesByName[c.Name()] += uint(c.count)
+	}
+	for _, n := range names {
+		if copiesByName[n] > MaxCopiesPerName {
+			errs = append(errs, fmt.Errorf("%w, %v of %v", ErrTooManyCopies, copiesByName[n], n))
+		}
+	}
+
+	hasBasic := false
+	var unknownCards []string
+	for _, c := range d.cards {
+		base := c.printings[0].card.Base()
+		switch {
+		case base.Kind() == data.CardKindUnknown:
+			unknownCards = append(unknownCards, c.Name())
+		case base.Kind() == data.CardKindPokemon && base.Stage() == data.StageBasic:
+			hasBasic = true
+		case base.Kind() == data.CardKindPokemon && base.Stage() == data.StageUnknown:
+			unknownCards = append(unknownCards, c.Name())
+		}
+	}
+	if !hasBasic {
+		if len(unknownCards) > 0 {
+			errs = append(errs, fmt.Errorf("%w, can't check for Basic Pokémon: %v", ErrUnknownCardDetails, unknownCards))
+		} else {
+			errs = append(errs, ErrNoBasicPokemon)
+		}
+	}
+
+	if energyTypes := d.EnergyTypes(); len(energyTypes) > MaxEnergyZoneTypes {
+		errs = append(errs, fmt.Errorf("%w, %v", ErrTooManyEnergyTypes, energyTypes))
+	}
+	return errs
+}
@@ -0,0 +1,58 @@
+package deck
+
+import (
+	"ptcgpocket/data"
+	"ptcgpocket/userdata"
+)
+
+// Copies owned across all printings of the card.
+func (c *DeckCard) OwnedCopies(userCollection *userdata.UserCollection) uint8 {
+	var owned uint8
+	for _, p := range c.printings {
+		if eCollection := userCollection.GetExpansionCollection(p.expansion.Id()); eCollection != nil {
+			owned += eCollection.Copies(p.card)
+		}
+	}
+	return owned
+}
+
+func (c *DeckCard) MissingCopies(userCollection *userdata.UserCollection) uint8 {
+	return c.count - min(c.count, c.OwnedCopies(userCollection))
+}
+
+// A must-have wishlist of the deck's missing copies, each wanted as its
+// cheapest printing as PlanBuild obtains them. Any printing counts as owned,
+// so the copies wanted of a printing are those already owned of it plus the
+// deck's missing copies.
+func NewMissingWishlist(deck *Deck, userCollection *userdata.UserCollection) *userdata.Wishlist {
+	cards := make(map[data.ExpansionId][]*data.Card)
+	copies := make(map[data.ExpansionId]map[data.ExpansionCardNumber]uint8)
+	for _, c := range deck.cards {
+		missingCopies := c.MissingCopies(userCollection)
+		if missingCopies == 0 {
+			continue
+		}
+		p := cheapestPrinting(c)
+		eId := p.expansion.Id()
+		if copies[eId] == nil {
+			copies[eId] = make(map[data.ExpansionCardNumber]uint8)
+		}
+		if _, listed := copies[eId][p.card.Number()]; !listed {
+			cards[eId] = append(cards[eId], p.card)
+			if eCollection := userCollection.GetExpansionCollection(eId); eCollection != nil {
+				copies[eId][p.card.Number()] = eCollection.Copies(p.card)
+			}
+		}
+		copies[eId][p.card.Number()] += missingCopies
+	}
+
+	expansionWishlists := make(map[data.ExpansionId]*userdata.ExpansionWishlist, len(cards))
+	for eId, eCards := range cards {
+		weights := make(data.CardWeights, len(eCards))
+		for _, c := range eCards {
+			weights[c.Number()] = userdata.PriorityMustHave.Weight()
+		}
+		expansionWishlists[eId] = userdata.NewExpansionWishlist(eCards, weights, copies[eId])
+	}
+	return userdata.NewWishlist(deck.name, expansionWishlists)
+}
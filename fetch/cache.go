@@ -0,0 +1,272 @@
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const metadataSuffix = ".meta.json"
+
+var ErrOffline = errors.New("page not cached and running offline")
+
+// A cached page and where it came from.
+type CacheEntry struct {
+	url          string
+	path         string
+	fetchedAt    time.Time
+	checksum     string
+	etag         string
+	lastModified string
+	size         int64
+	// Whether the page's checksum matches the one recorded when fetched
+	checksumValid bool
+	// Pages cached before metadata was recorded only have a path
+	hasMetadata bool
+}
+
+func (e *CacheEntry) Url() string {
+	return e.url
+}
+
+func (e *CacheEntry) Path() string {
+	return e.path
+}
+
+func (e *CacheEntry) FetchedAt() time.Time {
+	return e.fetchedAt
+}
+
+// Hex encoded SHA-256 of the page.
+func (e *CacheEntry) Checksum() string {
+	return e.checksum
+}
+
+func (e *CacheEntry) Size() int64 {
+	return e.size
+}
+
+func (e *CacheEntry) ChecksumValid() bool {
+	return e.checksumValid
+}
+
+func (e *CacheEntry) HasMetadata() bool {
+	return e.hasMetadata
+}
+
+type serialisedCacheMetadata struct {
+	Url          string    `json:"url"`
+	FetchedAt    time.Time `json:"fetchedAt"`
+	Checksum     string    `json:"checksum"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+}
+
+func checksum(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Pages cached on disk under <dir>/<host>/<path>, with metadata alongside
+// each page. Pages older than the max age are revalidated with the server
+// before use.
+type Cache struct {
+	dir     string
+	fetcher *Fetcher
+	// 0 to never revalidate
+	maxAge time.Duration
+	// Never use the network, failing when a page isn't cached
+	offline bool
+}
+
+func NewCache(dir string, fetcher *Fetcher, maxAge time.Duration, offline bool) *Cache {
+	return &Cache{dir: dir, fetcher: fetcher, maxAge: maxAge, offline: offline}
+}
+
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+func (c *Cache) IsOffline() bool {
+	return c.offline
+}
+
+func (c *Cache) pagePath(pageUrl string) (string, error) {
+	parsed, uErr := url.Parse(pageUrl)
+	if uErr != nil {
+		return "", fmt.Errorf("error parsing URL: %w", uErr)
+	}
+	return filepath.Join(c.dir, parsed.Hostname(), parsed.Path), nil
+}
+
+// Reads the cached page, nil when it isn't cached or is corrupt.
+func (c *Cache) readEntry(path string) (*CacheEntry, []byte) {
+	body, rErr := os.ReadFile(path)
+	if rErr != nil {
+		return nil, nil
+	}
+	entry := &CacheEntry{path: path, size: int64(len(body)), checksum: checksum(body), checksumValid: true}
+
+	raw, mErr := os.ReadFile(path + metadataSuffix)
+	if mErr != nil {
+		// Cached before metadata, treat as fetched when last written
+		if info, sErr := os.Stat(path); sErr == nil {
+			entry.fetchedAt = info.ModTime()
+		}
+		return entry, body
+	}
+	var metadata serialisedCacheMetadata
+	if uErr := json.Unmarshal(raw, &metadata); uErr != nil {
+		return entry, body
+	}
+	entry.hasMetadata = true
+	entry.url = metadata.Url
+	entry.fetchedAt = metadata.FetchedAt
+	entry.etag = metadata.ETag
+	entry.lastModified = metadata.LastModified
+	entry.checksumValid = metadata.Checksum == entry.checksum
+	return entry, body
+}
+
+func (c *Cache) writeEntry(path string, pageUrl string, response *Response, body []byte) error {
+	if mErr := os.MkdirAll(filepath.Dir(path), 0755); mErr != nil {
+		return mErr
+	}
+	if wErr := os.WriteFile(path, body, 0644); wErr != nil {
+		return wErr
+	}
+	raw, jErr := json.MarshalIndent(&serialisedCacheMetadata{
+		Url:          pageUrl,
+		FetchedAt:    time.Now().UTC(),
+		Checksum:     checksum(body),
+		ETag:         response.Validators().ETag(),
+		LastModified: response.Validators().LastModified(),
+	}, "", "  ")
+	if jErr != nil {
+		return jErr
+	}
+	return os.WriteFile(path+metadataSuffix, raw, 0644)
+}
+
+func (c *Cache) isFresh(entry *CacheEntry) bool {
+	return c.maxAge <= 0 || time.Since(entry.fetchedAt) <= c.maxAge
+}
+
+// Returns the page, from the cache when it's fresh. Stale pages are
+// revalidated, falling back to the stale copy if the server can't be reached.
+func (c *Cache) Get(ctx context.Context, pageUrl string) ([]byte, error) {
+	path, pErr := c.pagePath(pageUrl)
+	if pErr != nil {
+		return nil, pErr
+	}
+	entry, body := c.readEntry(path)
+	if entry != nil && !entry.checksumValid {
+		fmt.Fprintf(os.Stderr, "Cached %v doesn't match its checksum, ignoring it\n", pageUrl)
+		entry, body = nil, nil
+	}
+
+	if c.offline {
+		if entry == nil {
+			return nil, fmt.Errorf("%w: %v", ErrOffline, pageUrl)
+		}
+		return body, nil
+	}
+	if entry != nil && c.isFresh(entry) {
+		return body, nil
+	}
+
+	if entry == nil {
+		fmt.Printf("No cached file found, fetching %v\n", pageUrl)
+	}
+	fetched, fErr := c.fetch(ctx, path, pageUrl, entry, body)
+	if fErr != nil && entry != nil && ctx.Err() == nil {
+		fmt.Fprintf(os.Stderr, "Couldn't revalidate %v, using cached copy: %v\n", pageUrl, fErr)
+		return body, nil
+	}
+	return fetched, fErr
+}
+
+// Fetches the page, conditionally when an entry with validators exists, and
+// stores it.
+func (c *Cache) fetch(
+	ctx context.Context,
+	path string,
+	pageUrl string,
+	entry *CacheEntry,
+	body []byte,
+) ([]byte, error) {
+	var validators *Validators
+	if entry != nil {
+		validators = NewValidators(entry.etag, entry.lastModified)
+	}
+	response, fErr := c.fetcher.Fetch(ctx, pageUrl, validators)
+	if fErr != nil {
+		return nil, fErr
+	}
+	if response.NotModified() {
+		return body, c.writeEntry(path, pageUrl, response, body)
+	}
+	return response.Body(), c.writeEntry(path, pageUrl, response, response.Body())
+}
+
+// Revalidates the page regardless of its age, returning whether it changed.
+func (c *Cache) Refresh(ctx context.Context, pageUrl string) (bool, error) {
+	if c.offline {
+		return false, fmt.Errorf("%w: %v", ErrOffline, pageUrl)
+	}
+	path, pErr := c.pagePath(pageUrl)
+	if pErr != nil {
+		return false, pErr
+	}
+	entry, body := c.readEntry(path)
+	if entry != nil && !entry.checksumValid {
+		entry, body = nil, nil
+	}
+	fetched, fErr := c.fetch(ctx, path, pageUrl, entry, body)
+	if fErr != nil {
+		return false, fErr
+	}
+	return entry == nil || checksum(fetched) != entry.checksum, nil
+}
+
+// Every cached page, ordered by path.
+func (c *Cache) Entries() ([]*CacheEntry, error) {
+	var entries []*CacheEntry
+	wErr := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(path, metadataSuffix) {
+			return nil
+		}
+		if entry, _ := c.readEntry(path); entry != nil {
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if wErr != nil {
+		return nil, wErr
+	}
+	slices.SortFunc(entries, func(e1, e2 *CacheEntry) int {
+		return strings.Compare(e1.path, e2.path)
+	})
+	return entries, nil
+}
+
+// Removes every cached page.
+func (c *Cache) Clear() error {
+	return os.RemoveAll(c.dir)
+}
@@ -0,0 +1,75 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheGet(t *testing.T) {
+	var requests, conditionalRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			conditionalRequests.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("page"))
+	}))
+	defer server.Close()
+	pageUrl := server.URL + "/tcgpocket/booster.shtml"
+
+	dir := t.TempDir()
+	fetcher := newTestFetcher(DefaultOptions)
+	cache := NewCache(dir, fetcher, time.Hour, false)
+	for range 2 {
+		body, err := cache.Get(context.Background(), pageUrl)
+		if err != nil || string(body) != "page" {
+			t.Fatalf("Get = %v, %v; want page", string(body), err)
+		}
+	}
+	if requests.Load() != 1 {
+		t.Errorf("Requests = %v; want 1 with the second from the cache", requests.Load())
+	}
+
+	entries, eErr := cache.Entries()
+	if eErr != nil || len(entries) != 1 {
+		t.Fatalf("Entries = %v, %v; want 1 entry", entries, eErr)
+	}
+	if entries[0].Url() != pageUrl || !entries[0].ChecksumValid() {
+		t.Errorf("Entry url = %v, checksum valid = %v; want %v, true", entries[0].Url(), entries[0].ChecksumValid(), pageUrl)
+	}
+	info, _ := os.Stat(entries[0].Path())
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("Cached page mode = %v; want 0644", info.Mode().Perm())
+	}
+
+	// Everything is stale with no max age left
+	staleCache := NewCache(dir, fetcher, time.Nanosecond, false)
+	if body, err := staleCache.Get(context.Background(), pageUrl); err != nil || string(body) != "page" {
+		t.Errorf("Stale get = %v, %v; want page", string(body), err)
+	}
+	if conditionalRequests.Load() != 1 {
+		t.Errorf("Conditional requests = %v; want 1", conditionalRequests.Load())
+	}
+
+	// Corrupted pages are fetched again
+	os.WriteFile(entries[0].Path(), []byte("corrupt"), 0644)
+	if body, err := cache.Get(context.Background(), pageUrl); err != nil || string(body) != "page" {
+		t.Errorf("Corrupt get = %v, %v; want page", string(body), err)
+	}
+}
+
+func TestCacheOffline(t *testing.T) {
+	cache := NewCache(t.TempDir(), newTestFetcher(DefaultOptions), 0, true)
+	if _, err := cache.Get(context.Background(), "http://example.com/page"); !errors.Is(err, ErrOffline) {
+		t.Errorf("Offline get error = %v; want ErrOffline", err)
+	}
+}
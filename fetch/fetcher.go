@@ -0,0 +1,360 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const DefaultUserAgent = "ptcgpocket-booster-sim/1.0"
+
+// How requests are made and retried.
+type Options struct {
+	// Per attempt, 0 for no timeout
+	timeout     time.Duration
+	maxAttempts int
+	// Doubled after each failed attempt, up to maxBackoff
+	initialBackoff     time.Duration
+	maxBackoff         time.Duration
+	perHostConcurrency int
+	// Least time between the starts of requests to a host
+	perHostInterval time.Duration
+	userAgent       string
+}
+
+func NewOptions(
+	timeout time.Duration,
+	maxAttempts int,
+	initialBackoff time.Duration,
+	maxBackoff time.Duration,
+	perHostConcurrency int,
+	perHostInterval time.Duration,
+	userAgent string,
+) *Options {
+	return &Options{
+		timeout:            timeout,
+		maxAttempts:        max(maxAttempts, 1),
+		initialBackoff:     initialBackoff,
+		maxBackoff:         max(maxBackoff, initialBackoff),
+		perHostConcurrency: max(perHostConcurrency, 1),
+		perHostInterval:    max(perHostInterval, 0),
+		userAgent:          userAgent,
+	}
+}
+
+var DefaultOptions = NewOptions(
+	30*time.Second,
+	4,
+	time.Second,
+	30*time.Second,
+	2,
+	500*time.Millisecond,
+	DefaultUserAgent,
+)
+
+func (o *Options) Timeout() time.Duration {
+	return o.timeout
+}
+
+func (o *Options) MaxAttempts() int {
+	return o.maxAttempts
+}
+
+func (o *Options) PerHostConcurrency() int {
+	return o.perHostConcurrency
+}
+
+func (o *Options) PerHostInterval() time.Duration {
+	return o.perHostInterval
+}
+
+func (o *Options) UserAgent() string {
+	return o.userAgent
+}
+
+// Returned for responses that aren't a success, after any retries.
+type StatusError struct {
+	url        string
+	statusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("fetching %v: unexpected status %v %v", e.url, e.statusCode, http.StatusText(e.statusCode))
+}
+
+func (e *StatusError) StatusCode() int {
+	return e.statusCode
+}
+
+// Whether a failed request is worth trying again.
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// Identify a previously fetched version of a page, for conditional requests.
+type Validators struct {
+	etag         string
+	lastModified string
+}
+
+func NewValidators(etag string, lastModified string) *Validators {
+	return &Validators{etag: etag, lastModified: lastModified}
+}
+
+func (v *Validators) ETag() string {
+	return v.etag
+}
+
+func (v *Validators) LastModified() string {
+	return v.lastModified
+}
+
+func (v *Validators) IsEmpty() bool {
+	return v == nil || (v.etag == "" && v.lastModified == "")
+}
+
+type Response struct {
+	url string
+	// Nil when not modified
+	body        []byte
+	notModified bool
+	validators  *Validators
+}
+
+func (r *Response) Url() string {
+	return r.url
+}
+
+func (r *Response) Body() []byte {
+	return r.body
+}
+
+// Whether the page is unchanged since the validators passed to Fetch, in
+// which case there's no body.
+func (r *Response) NotModified() bool {
+	return r.notModified
+}
+
+// Validators to revalidate this version of the page with.
+func (r *Response) Validators() *Validators {
+	return r.validators
+}
+
+// Requests in flight to a host and when the next may start.
+type hostLimit struct {
+	slots chan struct{}
+
+	nextLock sync.Mutex
+	next     time.Time
+}
+
+// Fetches pages over HTTP, shared so requests to the same host are limited
+// in concurrency and rate, and failed requests are retried with backoff.
+type Fetcher struct {
+	client  *http.Client
+	options *Options
+
+	hostsLock sync.Mutex
+	hosts     map[string]*hostLimit
+	// Replaced in tests to avoid waiting
+	sleep func(ctx context.Context, d time.Duration) error
+	now   func() time.Time
+}
+
+func NewFetcher(client *http.Client, options *Options) *Fetcher {
+	return &Fetcher{
+		client:  client,
+		options: options,
+		hosts:   make(map[string]*hostLimit),
+		sleep:   sleepContext,
+		now:     time.Now,
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *Fetcher) Options() *Options {
+	return f.options
+}
+
+func (f *Fetcher) acquireHost(ctx context.Context, host string) (*hostLimit, func(), error) {
+	f.hostsLock.Lock()
+	h, hFound := f.hosts[host]
+	if !hFound {
+		h = &hostLimit{slots: make(chan struct{}, f.options.perHostConcurrency)}
+		f.hosts[host] = h
+	}
+	f.hostsLock.Unlock()
+
+	select {
+	case h.slots <- struct{}{}:
+		return h, func() { <-h.slots }, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+// Waits for the host's next request start, at least PerHostInterval after
+// the previous one and after any Retry-After the host sent.
+func (f *Fetcher) waitForHost(ctx context.Context, h *hostLimit) error {
+	h.nextLock.Lock()
+	now := f.now()
+	start := now
+	if h.next.After(now) {
+		start = h.next
+	}
+	h.next = start.Add(f.options.perHostInterval)
+	h.nextLock.Unlock()
+
+	if start.After(now) {
+		return f.sleep(ctx, start.Sub(now))
+	}
+	return nil
+}
+
+// Holds back every request to the host until the delay has passed.
+func (f *Fetcher) delayHost(h *hostLimit, delay time.Duration) {
+	h.nextLock.Lock()
+	defer h.nextLock.Unlock()
+	if until := f.now().Add(delay); until.After(h.next) {
+		h.next = until
+	}
+}
+
+// Delay from a Retry-After header in seconds or as an HTTP date, false when
+// there isn't a valid one.
+func (f *Fetcher) retryAfterDelay(retryAfter string) (time.Duration, bool) {
+	if seconds, sErr := strconv.Atoi(retryAfter); sErr == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, dErr := http.ParseTime(retryAfter); dErr == nil {
+		return max(date.Sub(f.now()), 0), true
+	}
+	return 0, false
+}
+
+// Delay before the attempt after the given one, from the Retry-After header
+// when the server sent one.
+func (f *Fetcher) backoff(attempt int, retryAfter string) time.Duration {
+	if delay, dFound := f.retryAfterDelay(retryAfter); dFound {
+		return min(delay, f.options.maxBackoff)
+	}
+	backoff := f.options.initialBackoff << (attempt - 1)
+	if backoff <= 0 || backoff > f.options.maxBackoff {
+		backoff = f.options.maxBackoff
+	}
+	// Up to 50% jitter so concurrent retries spread out
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+// Fetches the page, retrying on network errors, 429 and 5xx responses. A
+// Retry-After on those delays every request to the host. When validators are
+// given the request is conditional and an unchanged page is returned with
+// NotModified set. Any other non 200 response is a StatusError.
+func (f *Fetcher) Fetch(ctx context.Context, pageUrl string, validators *Validators) (*Response, error) {
+	parsed, uErr := url.Parse(pageUrl)
+	if uErr != nil {
+		return nil, fmt.Errorf("error parsing URL: %w", uErr)
+	}
+	host, release, aErr := f.acquireHost(ctx, parsed.Host)
+	if aErr != nil {
+		return nil, aErr
+	}
+	defer release()
+
+	var lastErr error
+	for attempt := 1; attempt <= f.options.maxAttempts; attempt++ {
+		if wErr := f.waitForHost(ctx, host); wErr != nil {
+			return nil, wErr
+		}
+		response, retryAfter, err := f.fetchOnce(ctx, pageUrl, validators)
+		if err == nil {
+			return response, nil
+		}
+		lastErr = err
+
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && !isRetryableStatus(statusErr.statusCode) {
+			return nil, err
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if attempt == f.options.maxAttempts {
+			break
+		}
+		backoff := f.backoff(attempt, retryAfter)
+		if retryAfter != "" {
+			f.delayHost(host, backoff)
+		}
+		if sErr := f.sleep(ctx, backoff); sErr != nil {
+			return nil, sErr
+		}
+	}
+	return nil, fmt.Errorf("giving up after %v attempts: %w", f.options.maxAttempts, lastErr)
+}
+
+// Makes a single attempt, also returning the Retry-After header.
+func (f *Fetcher) fetchOnce(ctx context.Context, pageUrl string, validators *Validators) (*Response, string, error) {
+	attemptCtx := ctx
+	if f.options.timeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, f.options.timeout)
+		defer cancel()
+	}
+
+	request, rErr := http.NewRequestWithContext(attemptCtx, http.MethodGet, pageUrl, nil)
+	if rErr != nil {
+		return nil, "", rErr
+	}
+	request.Header.Set("User-Agent", f.options.userAgent)
+	if !validators.IsEmpty() {
+		if validators.etag != "" {
+			request.Header.Set("If-None-Match", validators.etag)
+		}
+		if validators.lastModified != "" {
+			request.Header.Set("If-Modified-Since", validators.lastModified)
+		}
+	}
+
+	resp, dErr := f.client.Do(request)
+	if dErr != nil {
+		return nil, "", dErr
+	}
+	defer resp.Body.Close()
+
+	responseValidators := NewValidators(resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"))
+	switch {
+	case resp.StatusCode == http.StatusNotModified && !validators.IsEmpty():
+		if responseValidators.IsEmpty() {
+			responseValidators = validators
+		}
+		return &Response{url: pageUrl, notModified: true, validators: responseValidators}, "", nil
+	case resp.StatusCode != http.StatusOK:
+		// Drain so the connection can be reused
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, resp.Header.Get("Retry-After"), &StatusError{url: pageUrl, statusCode: resp.StatusCode}
+	}
+
+	body, bErr := io.ReadAll(resp.Body)
+	if bErr != nil {
+		return nil, "", bErr
+	}
+	return &Response{url: pageUrl, body: body, validators: responseValidators}, "", nil
+}
@@ -0,0 +1,201 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestFetcher(options *Options) *Fetcher {
+	f := NewFetcher(&http.Client{}, options)
+	f.sleep = func(ctx context.Context, d time.Duration) error {
+		return ctx.Err()
+	}
+	return f
+}
+
+func TestFetchRetriesServerErrors(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != "test-agent" {
+			t.Errorf("User-Agent = %v; want test-agent", r.Header.Get("User-Agent"))
+		}
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("page"))
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(NewOptions(time.Second, 3, time.Millisecond, time.Millisecond, 1, 0, "test-agent"))
+	response, err := f.Fetch(context.Background(), server.URL, nil)
+	if err != nil {
+		t.Fatalf("Fetch error = %v; want nil", err)
+	}
+	if string(response.Body()) != "page" {
+		t.Errorf("Fetch body = %v; want page", string(response.Body()))
+	}
+	if requests.Load() != 3 {
+		t.Errorf("Requests = %v; want 3", requests.Load())
+	}
+}
+
+func TestFetchDoesNotRetryNotFound(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(NewOptions(time.Second, 3, time.Millisecond, time.Millisecond, 1, 0, "test-agent"))
+	_, err := f.Fetch(context.Background(), server.URL, nil)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode() != http.StatusNotFound {
+		t.Errorf("Fetch error = %v; want 404 status error", err)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("Requests = %v; want 1", requests.Load())
+	}
+}
+
+func TestFetchRevalidates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 02 Jun 2025 10:00:00 GMT")
+		w.Write([]byte("page"))
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(DefaultOptions)
+	first, err := f.Fetch(context.Background(), server.URL, nil)
+	if err != nil {
+		t.Fatalf("Fetch error = %v; want nil", err)
+	}
+	if first.Validators().ETag() != `"v1"` || first.Validators().LastModified() == "" {
+		t.Errorf("Fetch validators = %+v; want ETag and Last-Modified", first.Validators())
+	}
+
+	second, err := f.Fetch(context.Background(), server.URL, first.Validators())
+	if err != nil {
+		t.Fatalf("Conditional fetch error = %v; want nil", err)
+	}
+	if !second.NotModified() || second.Body() != nil {
+		t.Errorf("Conditional fetch not modified = %v; want true without a body", second.NotModified())
+	}
+	if second.Validators().ETag() != `"v1"` {
+		t.Errorf("Conditional fetch ETag = %v; want the original", second.Validators().ETag())
+	}
+}
+
+func TestFetchLimitsConcurrencyPerHost(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(NewOptions(time.Second, 1, 0, 0, 2, 0, "test-agent"))
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := f.Fetch(context.Background(), server.URL, nil); err != nil {
+				t.Errorf("Fetch error = %v; want nil", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if maxInFlight.Load() > 2 {
+		t.Errorf("Most concurrent requests = %v; want at most 2", maxInFlight.Load())
+	}
+}
+
+func TestFetchTimesOut(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(NewOptions(10*time.Millisecond, 2, 0, 0, 1, 0, "test-agent"))
+	if _, err := f.Fetch(context.Background(), server.URL, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Fetch error = %v; want deadline exceeded", err)
+	}
+}
+
+// A fetcher whose clock only moves when it sleeps, recording each sleep.
+func newFakeClockFetcher(options *Options) (*Fetcher, *[]time.Duration) {
+	f := NewFetcher(&http.Client{}, options)
+	now := time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC)
+	var sleeps []time.Duration
+	f.now = func() time.Time {
+		return now
+	}
+	f.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		now = now.Add(d)
+		return ctx.Err()
+	}
+	return f, &sleeps
+}
+
+func TestFetchSpacesRequestsPerHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page"))
+	}))
+	defer server.Close()
+
+	f, sleeps := newFakeClockFetcher(NewOptions(time.Second, 1, 0, 0, 1, 100*time.Millisecond, "test-agent"))
+	for range 3 {
+		if _, err := f.Fetch(context.Background(), server.URL, nil); err != nil {
+			t.Fatalf("Fetch error = %v; want nil", err)
+		}
+	}
+	if len(*sleeps) != 2 || (*sleeps)[0] != 100*time.Millisecond || (*sleeps)[1] != 100*time.Millisecond {
+		t.Errorf("Sleeps = %v; want 100ms before each request after the first", *sleeps)
+	}
+}
+
+func TestFetchRetryAfterDate(t *testing.T) {
+	var requests atomic.Int32
+	var f *Fetcher
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", f.now().Add(5*time.Second).Format(http.TimeFormat))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("page"))
+	}))
+	defer server.Close()
+
+	f, sleeps := newFakeClockFetcher(NewOptions(time.Second, 2, time.Millisecond, 30*time.Second, 1, 0, "test-agent"))
+	if _, err := f.Fetch(context.Background(), server.URL, nil); err != nil {
+		t.Fatalf("Fetch error = %v; want nil", err)
+	}
+	if len(*sleeps) != 1 || (*sleeps)[0] != 5*time.Second {
+		t.Errorf("Sleeps = %v; want 5s from the Retry-After date", *sleeps)
+	}
+}
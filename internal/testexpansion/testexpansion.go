@@ -0,0 +1,87 @@
+package testexpansion
+
+import (
+	"fmt"
+	"ptcgpocket/data"
+)
+
+// An expansion of a single booster offering the cards at the rates.
+func NewWithRates(
+	id data.ExpansionId,
+	name string,
+	code string,
+	cards []*data.Card,
+	rates data.OfferingRatesTable,
+) *data.Expansion {
+	booster, err := data.NewBooster("Test booster", cards, rates, 0, 0.9995, 0, 0.0005, data.DefaultBoosterOptions)
+	if err != nil {
+		panic(err)
+	}
+	return data.NewExpansion(id, name, code, []*data.Booster{booster})
+}
+
+// An expansion named and coded by its id, whose booster only offers the
+// first card's rarity. The cards' other rarities are never offered.
+func New(id data.ExpansionId, cards []*data.Card) *data.Expansion {
+	rates := make(data.OfferingRatesTable)
+	for _, c := range cards {
+		rates[c.Rarity()] = *data.NewBoosterOffering(0, 0, 0, 0)
+	}
+	rates[cards[0].Rarity()] = *data.NewBoosterOffering(100.0, 100.0, 100.0, 100.0)
+	return NewWithRates(id, string(id), string(id), cards, rates)
+}
+
+// A booster shaped like Genetic Apex, with roughly 280 cards.
+func GeneticApexShapedBooster(options *data.BoosterOptions) *data.Booster {
+	rarityCounts := []struct {
+		rarity *data.Rarity
+		count  int
+	}{
+		{data.RarityOneDiamond, 100},
+		{data.RarityTwoDiamond, 70},
+		{data.RarityThreeDiamond, 35},
+		{data.RarityFourDiamond, 25},
+		{data.RarityOneStar, 25},
+		{data.RarityTwoStar, 15},
+		{data.RarityThreeStar, 5},
+		{data.RarityCrown, 3},
+	}
+	var cards []*data.Card
+	var number data.ExpansionCardNumber = 1
+	for _, rc := range rarityCounts {
+		for range rc.count {
+			cards = append(cards, data.NewCard(data.NewBaseCard(fmt.Sprintf("Card %v", number), 60, 1), number, rc.rarity))
+			number++
+		}
+	}
+
+	booster, err := data.NewBooster(
+		"Test booster",
+		cards,
+		data.OfferingRatesTable{
+			data.RarityOneDiamond:   *data.NewBoosterOffering(100.0, 0, 0, 0),
+			data.RarityTwoDiamond:   *data.NewBoosterOffering(0, 90.0, 60.0, 0),
+			data.RarityThreeDiamond: *data.NewBoosterOffering(0, 5.0, 20.0, 0),
+			data.RarityFourDiamond:  *data.NewBoosterOffering(0, 1.666, 6.664, 0),
+			data.RarityOneStar:      *data.NewBoosterOffering(0, 2.572, 10.288, 40.0),
+			data.RarityTwoStar:      *data.NewBoosterOffering(0, 0.50, 0.200, 50.0),
+			data.RarityThreeStar:    *data.NewBoosterOffering(0, 0.222, 0.888, 5.0),
+			data.RarityCrown:        *data.NewBoosterOffering(0, 0.4, 0.16, 5.0),
+		},
+		number-1,
+		0.9995,
+		0,
+		0.0005,
+		options,
+	)
+	if err != nil {
+		panic(err)
+	}
+	return booster
+}
+
+// An expansion named and coded by its id with a Genetic Apex shaped booster.
+func GeneticApexShaped(id data.ExpansionId) *data.Expansion {
+	booster := GeneticApexShapedBooster(data.DefaultBoosterOptions)
+	return data.NewExpansion(id, string(id), string(id), []*data.Booster{booster})
+}
@@ -1,19 +1,29 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"math"
+	"io/fs"
 	"math/rand/v2"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
 	"slices"
 	"strings"
+	"time"
 
+	"ptcgpocket/catalogue"
 	"ptcgpocket/data"
+	"ptcgpocket/deck"
+	"ptcgpocket/fetch"
 	"ptcgpocket/serebii"
 	"ptcgpocket/sim"
+	"ptcgpocket/trade"
 	"ptcgpocket/userdata"
 
 	"golang.org/x/sync/errgroup"
@@ -396,15 +406,121 @@ var expansionDataSources = [...]*serebii.ExpansionSerebiiSource{
 	),
 }
 
-func readUserData(expansions []*data.Expansion) (*userdata.UserData, error) {
+// Reads data.json, or the profiles directory when there isn't one.
+func readProfiles(expansions []*data.Expansion) (*userdata.Profiles, error) {
 	dir, dErr := os.Getwd()
 	if dErr != nil {
 		return nil, dErr
 	}
 
 	dataFilepath := filepath.Join(dir, "data.json")
+	if _, sErr := os.Stat(dataFilepath); errors.Is(sErr, fs.ErrNotExist) {
+		dataFilepath = filepath.Join(dir, "profiles")
+	}
+
+	return userdata.ReadProfilesFromFilepath(dataFilepath, expansions)
+}
+
+// The profile's user data, or the only profile's when profile is empty.
+func readUserData(expansions []*data.Expansion, profile string) (*userdata.UserData, error) {
+	profiles, pErr := readProfiles(expansions)
+	if pErr != nil {
+		return nil, pErr
+	}
+	return profiles.Get(profile)
+}
+
+// A flag that can be given more than once.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ", ")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// Imports a deck list file as a wishlist of the copies missing from the
+// collection, named after the file.
+func importDeck(
+	deckFilepath string,
+	expansions []*data.Expansion,
+	userCollection *userdata.UserCollection,
+) (*deck.Deck, *userdata.Wishlist, error) {
+	f, fErr := os.Open(deckFilepath)
+	if fErr != nil {
+		return nil, nil, fErr
+	}
+	defer f.Close()
 
-	return userdata.ReadFromFilepath(dataFilepath, expansions)
+	entries, pErr := deck.ParseList(f)
+	if pErr != nil {
+		return nil, nil, fmt.Errorf("%v: %w", deckFilepath, pErr)
+	}
+	name := strings.TrimSuffix(filepath.Base(deckFilepath), filepath.Ext(deckFilepath))
+	d, rErrs := deck.Resolve(name, entries, expansions)
+	for _, rErr := range rErrs {
+		fmt.Fprintf(os.Stderr, "Warning: %v: %v\n", deckFilepath, rErr)
+	}
+	return d, deck.NewMissingWishlist(d, userCollection), nil
+}
+
+func printDeckImport(d *deck.Deck, userCollection *userdata.UserCollection) {
+	printHeading1(fmt.Sprintf("Deck '%v' (%v cards)", d.Name(), d.TotalCards()))
+	for c := range d.Cards() {
+		missing := ""
+		if m := c.MissingCopies(userCollection); m > 0 {
+			missing = fmt.Sprintf(", missing %v", m)
+		}
+		fmt.Printf("    %v %v - owned %v%v\n", c.Count(), c.Preferred(), c.OwnedCopies(userCollection), missing)
+	}
+
+	validationErrs := d.Validate()
+	if len(validationErrs) == 0 {
+		fmt.Println("  Valid deck")
+	}
+	for _, vErr := range validationErrs {
+		if errors.Is(vErr, deck.ErrUnknownCardDetails) {
+			fmt.Printf("  Unchecked: %v\n", vErr)
+			continue
+		}
+		fmt.Printf("  \033[0;31mInvalid: %v\033[0m\n", vErr)
+	}
+	var energyTypes []string
+	for _, t := range d.EnergyTypes() {
+		energyTypes = append(energyTypes, string(t))
+	}
+	if len(energyTypes) > 0 {
+		fmt.Printf("  Energy zone: %v\n", strings.Join(energyTypes, ", "))
+	}
+
+	plan := deck.PlanBuild(d, userCollection)
+	if plan.IsBuildable() {
+		fmt.Println("  Buildable from the collection")
+		return
+	}
+	heading := "Cheapest path to build"
+	if plan.ExpectedPacks() > 0 {
+		heading += printer.Sprintf(" (up to %.0f packs)", plan.ExpectedPacks())
+	}
+	printHeading2(heading)
+	for s := range plan.Steps() {
+		source := ""
+		if b, bFound := s.Booster(); bFound && s.Method() == deck.BuildByOpening {
+			source = fmt.Sprintf(" of %v", b.Name())
+		}
+		printer.Printf(
+			"    %vx %v: %v (%d pack points, %.0f packs%v)\n",
+			s.Copies(),
+			s.Printing(),
+			s.Method(),
+			s.PackPoints(),
+			s.ExpectedPacks(),
+			source,
+		)
+	}
 }
 
 func printHeading1(heading string) {
@@ -415,19 +531,10 @@ func printHeading2(heading string) {
 	fmt.Printf("\033[32m  ## %v\n\033[0m", heading)
 }
 
-func printFullCardPosition(
-	label string,
-	amount float64,
-	tallies map[*data.Rarity]float64,
-) {
-	allTallyDescriptions := make([]string, len(tallies))
-	i := 0
-	for _, r := range data.OrderedRarities {
-		t, tFound := tallies[r]
-		if tFound && t > 0 {
-			allTallyDescriptions[i] = fmt.Sprintf("%v%.3f", r, t)
-			i++
-		}
+func printSlotAudit(slotAudit *data.OfferingSlotAudit) {
+	var allTallyDescriptions []string
+	for r, t := range slotAudit.RarityTotals() {
+		allTallyDescriptions = append(allTallyDescriptions, fmt.Sprintf("%v%.3f", r, t))
 	}
 	tallyDescriptionRows := slices.Chunk(allTallyDescriptions, 5)
 
@@ -435,71 +542,36 @@ func printFullCardPosition(
 	// for 4th or 5th cards
 	colour := ""
 	colourReset := ""
-	if math.Abs(amount-100.0) > 0.1 {
+	if !slotAudit.IsValid() {
 		colour = "\033[0;31m"
 		colourReset = "\033[0m"
 	}
 
-	fmt.Printf("%s   %v: %.2f / 100%%\n", colour, label, amount)
+	fmt.Printf("%s   %v: %.2f / 100%% (%+.3f)\n", colour, slotAudit.Slot(), slotAudit.Total(), slotAudit.Deviation())
 	for t := range tallyDescriptionRows {
 		fmt.Printf("      %v%s\n", strings.Join(t, " "), colourReset)
 	}
 }
 
-func printBoosterDataAudit(expansions []*data.Expansion) {
+func printBoosterDataAudit(expansions []*data.Expansion, policy data.OfferingDriftPolicy) {
 	printHeading1("Booster gathered data audit")
+	fmt.Printf("  Offering drift policy: %v\n", policy)
 	for _, e := range expansions {
 		for b := range e.Boosters() {
+			audit := b.Audit()
+			printHeading2(fmt.Sprintf("%v - %v", e.Name(), b.Name()))
+			for s := range audit.Slots() {
+				printSlotAudit(s)
+			}
 
-			offeringTallies := make(map[string]map[*data.Rarity]float64)
-			offeringTallies["1-3"] = make(map[*data.Rarity]float64)
-			offeringTallies["4"] = make(map[*data.Rarity]float64)
-			offeringTallies["5"] = make(map[*data.Rarity]float64)
-			// TODO: 6th card for regular + 1 ?
-			offeringTallies["rare"] = make(map[*data.Rarity]float64)
-
+			// Totals as used by the sampler, after the drift policy
 			totalRegularPackOffering := 0.0
 			totalRarePackOffering := 0.0
-			totalFirstToThirdOffering := 0.0
-			totalFourthOffering := 0.0
-			totalFifthOffering := 0.0
-			totalRareCardOffering := 0.0
 			for c := range b.Offerings() {
-				offeringTallies["1-3"][c.Card().Rarity()] += c.First3CardOffering()
-				offeringTallies["4"][c.Card().Rarity()] += c.FourthCardOffering()
-				offeringTallies["5"][c.Card().Rarity()] += c.FifthCardOffering()
-				offeringTallies["rare"][c.Card().Rarity()] += c.RareCardOffering()
-
 				totalRegularPackOffering += c.RegularPackOffering()
 				totalRarePackOffering += c.RarePackOffering()
-				totalFirstToThirdOffering += c.First3CardOffering()
-				totalFourthOffering += c.FourthCardOffering()
-				totalFifthOffering += c.FifthCardOffering()
-				totalRareCardOffering += c.RareCardOffering()
 			}
-
-			printHeading2(fmt.Sprintf("%v - %v", e.Name(), b.Name()))
-			printFullCardPosition(
-				"1-3",
-				totalFirstToThirdOffering,
-				offeringTallies["1-3"],
-			)
-			printFullCardPosition(
-				"4",
-				totalFourthOffering,
-				offeringTallies["4"],
-			)
-			printFullCardPosition(
-				"5",
-				totalFifthOffering,
-				offeringTallies["5"],
-			)
 			fmt.Printf("   total regular: %.2f / 500%%\n", totalRegularPackOffering)
-			printFullCardPosition(
-				"rare",
-				totalRareCardOffering,
-				offeringTallies["rare"],
-			)
 			fmt.Printf("   total rare: %.2f / 500%%\n", totalRarePackOffering)
 			fmt.Println()
 		}
@@ -509,7 +581,7 @@ func printBoosterDataAudit(expansions []*data.Expansion) {
 func printCurrentCollectionStats(expansions []*data.Expansion, userCollection *userdata.UserCollection) {
 	printHeading1("Current collection")
 	for _, e := range expansions {
-		missing, sExists := userCollection.MissingForExpansion(e.Id())
+		missing, sExists := userCollection.MissingSetForExpansion(e.Id())
 		if !sExists {
 			fmt.Printf("Set id %v not found\n", e.Id())
 			return
@@ -517,38 +589,36 @@ func printCurrentCollectionStats(expansions []*data.Expansion, userCollection *u
 
 		printHeading2(e.Name())
 
-		var totalStarSecretCardsCollected uint64
-		var totalCrownSecretCardsCollected uint64
+		// Secret cards collected by symbol, for each symbol in the expansion
 		var totalNonSecretCardsCollected uint64
-		var totalShinySecretCardsCollected uint64
+		var totalSecretCardsCollected uint64
+		secretCardsCollected := make(map[rune]uint64)
 		for c := range e.Cards() {
-			if !slices.Contains(missing, c) {
-				if c.Rarity().IsStar() {
-					totalStarSecretCardsCollected += 1
-				} else if c.Rarity().IsCrown() {
-					totalCrownSecretCardsCollected += 1
-				} else if c.Rarity().IsShiny() {
-					totalShinySecretCardsCollected += 1
-				} else {
+			if !c.Rarity().IsSecret() {
+				if !missing.Contains(c) {
 					totalNonSecretCardsCollected += 1
 				}
+				continue
+			}
+			if _, sFound := secretCardsCollected[c.Rarity().Symbol()]; !sFound {
+				secretCardsCollected[c.Rarity().Symbol()] = 0
+			}
+			if !missing.Contains(c) {
+				secretCardsCollected[c.Rarity().Symbol()] += 1
+				totalSecretCardsCollected += 1
 			}
 		}
-		totalCollectedIncludingSecrets := totalStarSecretCardsCollected +
-			totalShinySecretCardsCollected +
-			totalCrownSecretCardsCollected +
-			totalNonSecretCardsCollected
+		totalCollectedIncludingSecrets := totalNonSecretCardsCollected + totalSecretCardsCollected
 
-		rarityTypes := map[rune]uint64{
-			data.RarityStarChar:  totalStarSecretCardsCollected,
-			data.RarityCrownChar: totalCrownSecretCardsCollected,
-		}
-		if e.HasShiny() {
-			rarityTypes[data.RarityShinyChar] = totalShinySecretCardsCollected
-		}
 		var rarityCounts []string
-		for r, t := range rarityTypes {
-			rarityCounts = append(rarityCounts, fmt.Sprintf("%v: %v", string(r), t))
+		var symbolsShown []rune
+		for r := range data.Rarities.All() {
+			t, tFound := secretCardsCollected[r.Symbol()]
+			if !tFound || slices.Contains(symbolsShown, r.Symbol()) {
+				continue
+			}
+			symbolsShown = append(symbolsShown, r.Symbol())
+			rarityCounts = append(rarityCounts, fmt.Sprintf("%v: %v", string(r.Symbol()), t))
 		}
 
 		fmt.Printf(
@@ -566,7 +636,7 @@ func printCurrentCollectionStats(expansions []*data.Expansion, userCollection *u
 
 func printBoosterProbabilities(
 	heading string,
-	getTargets func(e *data.Expansion) ([]*data.Card, bool),
+	getTargets func(e *data.Expansion) (*data.CardSet, bool),
 	expansions []*data.Expansion,
 ) {
 	var allBoosters []boosterWithOrigin
@@ -595,43 +665,215 @@ func printBoosterProbabilities(
 	}
 }
 
+// Like printBoosterProbabilities, ranking boosters by the weighted chance of
+// obtaining a target card.
+func printWeightedBoosterScores(
+	heading string,
+	getTargets func(e *data.Expansion) (*data.CardSet, data.CardWeights, bool),
+	expansions []*data.Expansion,
+) {
+	var allBoosters []boosterWithOrigin
+	for _, e := range expansions {
+		missing, weights, sExists := getTargets(e)
+		if !sExists {
+			continue
+		}
+
+		for b := range e.Boosters() {
+			allBoosters = append(allBoosters, boosterWithOrigin{
+				booster:              b,
+				totalOfferingMissing: b.GetInstanceWeightedValueForMissing(missing, weights),
+				expansion:            e,
+			})
+		}
+	}
+	slices.SortFunc(allBoosters, func(a, b boosterWithOrigin) int {
+		return cmp.Compare(b.totalOfferingMissing, a.totalOfferingMissing)
+	})
+
+	printHeading1(heading)
+	for i, b := range allBoosters {
+		fmt.Printf("  %v) %.2f %v - %v\n", i+1, b.totalOfferingMissing, b.expansion.Name(), b.booster.Name())
+	}
+}
+
+// Lists wishlist cards still wanted, including further copies of owned ones,
+// that are affordable with current pack points, best weight per pack point
+// first.
+func printPackPointSuggestions(
+	wishlist *userdata.Wishlist,
+	expansions []*data.Expansion,
+	userCollection *userdata.UserCollection,
+) {
+	printHeading1(fmt.Sprintf("Wishlist '%v' pack point redemption suggestions", wishlist.Name()))
+	for _, e := range expansions {
+		cards, cFound := wishlist.CardsForExpansion(e.Id())
+		eCollection := userCollection.GetExpansionCollection(e.Id())
+		if !cFound || eCollection == nil {
+			continue
+		}
+		weights, _ := wishlist.WeightsForExpansion(e.Id())
+
+		var affordable []*data.Card
+		for _, c := range cards {
+			wanted := eCollection.Copies(c) < wishlist.CopiesWanted(e.Id(), c)
+			if wanted && eCollection.PackPointPool().CanRedeem(c.Rarity()) {
+				affordable = append(affordable, c)
+			}
+		}
+		if len(affordable) == 0 {
+			continue
+		}
+		valuePerPoint := func(c *data.Card) float64 {
+			return weights[c.Number()] / float64(c.Rarity().PackPointsToObtain())
+		}
+		slices.SortStableFunc(affordable, func(c1, c2 *data.Card) int {
+			return cmp.Compare(valuePerPoint(c2), valuePerPoint(c1))
+		})
+
+		heading := fmt.Sprintf("%v (%v pack points)", e.Name(), eCollection.PackPoints())
+		if eCollection.PackPointPool().IsFull() {
+			heading += ", full so opening packs earns none until some are spent"
+		}
+		printHeading2(heading)
+		for _, c := range affordable {
+			fmt.Printf(
+				"    %v) %v %v - weight %v for %v points\n",
+				c.Number(),
+				c.Rarity(),
+				c.Name(),
+				weights[c.Number()],
+				c.Rarity().PackPointsToObtain(),
+			)
+		}
+	}
+}
+
+// Booster weights for simulations, combining all wishlists with the rest of
+// the missing collection.
+func wishlistBoosterWeights(
+	expansions []*data.Expansion,
+	userData *userdata.UserData,
+) map[data.ExpansionId]data.CardWeights {
+	wishlists := slices.Collect(userData.Wishlists())
+	boosterWeights := make(map[data.ExpansionId]data.CardWeights, len(expansions))
+	for _, e := range expansions {
+		missing, _ := userData.Collection().MissingSetForExpansion(e.Id())
+		boosterWeights[e.Id()] = userdata.MergeWishlistWeights(wishlists, e.Id(), missing)
+	}
+	return boosterWeights
+}
+
 type expansionSimRunAmounts struct {
 	numOpened                      uint64
 	totalPackPoints                uint64
 	numCardsObtainedFromPackPoints uint64
 	numRarePacks                   uint64
+	duplicates                     *sim.DuplicateEstimate
+	// Packs opened by each run
+	packsOpened *sim.Distribution
+}
+
+// Prints progress to stderr, at most a few times a second.
+type simProgress struct {
+	runs        uint64
+	completed   uint64
+	lastPrinted time.Time
+}
+
+func (p *simProgress) increment() {
+	p.completed++
+	if p.completed != p.runs && time.Since(p.lastPrinted) < 200*time.Millisecond {
+		return
+	}
+	p.lastPrinted = time.Now()
+	if p.runs == 0 {
+		fmt.Fprint(os.Stderr, printer.Sprintf("\r  Simulated %d runs", p.completed))
+		return
+	}
+	fmt.Fprint(os.Stderr, printer.Sprintf("\r  Simulated %d / %d runs", p.completed, p.runs))
+	if p.completed == p.runs {
+		fmt.Fprintln(os.Stderr)
+	}
 }
 
 func runSimulations(
+	ctx context.Context,
 	title string,
 	runMode *runOptions,
 	expansions []*data.Expansion,
 	userCollection *userdata.UserCollection,
 	completePredicate sim.ExpansionSimCompletePredicate,
 ) error {
-	printHeading1(printer.Sprintf("%v - pack opening simulations (%d runs)", title, runMode.simulationRuns))
-	fmt.Printf("  Seed: %v\n", runMode.randomSeed)
+	if runMode.adaptiveOptions != nil {
+		printHeading1(printer.Sprintf(
+			"%v - pack opening simulations (until ±%.1f packs at 95%% confidence)",
+			title,
+			runMode.adaptiveOptions.Tolerance(),
+		))
+	} else {
+		printHeading1(printer.Sprintf("%v - pack opening simulations (%d runs)", title, runMode.simulationRuns))
+	}
+	fmt.Printf("  Seed: %v (%v sampling)\n", runMode.randomSeed, runMode.boosterOptions.SamplingMethod())
 	fmt.Println("  The number of booster openings required to complete the collection.")
 
-	simResults := make(chan *sim.SimRun, runMode.simulationRuns)
-	sim.RunAllSimulations(
-		expansions,
-		userCollection,
-		completePredicate,
-		runMode.simulationRuns,
-		runMode.randomSeed,
-		context.Background(),
-		simResults,
-	)
-	close(simResults)
+	simResults := make(chan *sim.SimRun, runtime.GOMAXPROCS(0))
+	var simErr error
+	var precision *sim.SimPrecision
+	progress := &simProgress{runs: runMode.simulationRuns}
+	go func() {
+		if runMode.adaptiveOptions != nil {
+			precision, simErr = sim.RunAdaptiveSimulations(
+				expansions,
+				userCollection,
+				completePredicate,
+				runMode.simOptions,
+				runMode.randomSeed,
+				runMode.simulationWorkers,
+				runMode.adaptiveOptions,
+				ctx,
+				simResults,
+			)
+		} else {
+			simErr = sim.RunAllSimulations(
+				expansions,
+				userCollection,
+				completePredicate,
+				runMode.simOptions,
+				runMode.simulationRuns,
+				runMode.randomSeed,
+				runMode.simulationWorkers,
+				ctx,
+				simResults,
+			)
+		}
+		close(simResults)
+	}()
+	if runMode.adaptiveOptions != nil {
+		// Total is unknown up front
+		progress.runs = 0
+	}
 
+	var timeline *sim.Timeline
+	if runMode.simOptions.RecordAcquisitions() {
+		timeline = sim.NewTimeline(expansions, userCollection)
+	}
 	expansionTotals := make(map[*data.Expansion]*expansionSimRunAmounts)
 	var total uint64
+	var completedRuns uint64
 	for r := range simResults {
+		completedRuns++
+		progress.increment()
+		if timeline != nil {
+			timeline.Add(r)
+		}
 		for e, run := range r.ExpansionRuns() {
 			eTotals := expansionTotals[e]
 			if eTotals == nil {
-				eTotals = &expansionSimRunAmounts{}
+				eTotals = &expansionSimRunAmounts{
+					duplicates:  sim.NewSimulatedDuplicates(),
+					packsOpened: &sim.Distribution{},
+				}
 				expansionTotals[e] = eTotals
 			}
 
@@ -639,67 +881,423 @@ func runSimulations(
 			eTotals.totalPackPoints += run.TotalPackPoints()
 			eTotals.numCardsObtainedFromPackPoints += run.NumCardsObtainedFromPackPoints()
 			eTotals.numRarePacks += run.NumRarePacks()
+			eTotals.duplicates.Add(run)
+			// Runs stopped by the pack budget would have needed more packs
+			// than any completed run
+			if runMode.showCosts {
+				if run.Completed() {
+					eTotals.packsOpened.Add(run.NumOpened())
+				} else {
+					eTotals.packsOpened.AddCensored()
+				}
+			}
 			total += run.NumOpened()
 		}
 	}
-	expansionAverages := make(map[*data.Expansion]*expansionSimRunAmounts)
-	var averagesTotal uint64
-	for e, t := range expansionTotals {
-		expansionAverages[e] = &expansionSimRunAmounts{
-			numOpened:                      t.numOpened / runMode.simulationRuns,
-			totalPackPoints:                t.totalPackPoints / runMode.simulationRuns,
-			numCardsObtainedFromPackPoints: t.numCardsObtainedFromPackPoints / runMode.simulationRuns,
-			numRarePacks:                   t.numRarePacks / runMode.simulationRuns,
+	if simErr != nil {
+		if !errors.Is(simErr, context.Canceled) {
+			return simErr
 		}
-		averagesTotal += t.numOpened / runMode.simulationRuns
+		fmt.Fprintln(os.Stderr)
+		printer.Printf("  Interrupted, showing partial results of %d runs\n", completedRuns)
 	}
+	if completedRuns == 0 {
+		return simErr
+	}
+	if precision != nil {
+		fmt.Fprintln(os.Stderr)
+		outcome := "reached"
+		if !precision.Converged() {
+			outcome = "not reached within the time budget"
+		}
+		printer.Printf(
+			"  Precision %v after %d runs in %v: total ±%.1f packs (95%% confidence)\n",
+			outcome,
+			precision.Runs(),
+			precision.Elapsed().Round(time.Millisecond),
+			precision.TotalStat().ConfidenceHalfWidth95(),
+		)
+	}
+
 	printer.Printf("  Total pack openings across all simulations: %d\n", total)
 	fmt.Println()
-	for e, a := range expansionAverages {
+	var averagesTotal uint64
+	for _, e := range expansions {
+		t, tFound := expansionTotals[e]
+		if !tFound {
+			continue
+		}
+		averagesTotal += t.numOpened / completedRuns
+
 		printHeading2(e.Name())
-		printer.Printf("     Packs opened        %v\n", a.numOpened)
-		printer.Printf("     Rare packs          %v\n", a.numRarePacks)
-		printer.Printf("     Cards from pack pts %v\n", a.numCardsObtainedFromPackPoints)
+		if precision != nil {
+			eStat := precision.ExpansionStat(e)
+			printer.Printf("     Packs opened        %.1f ±%.1f\n", eStat.Mean(), eStat.ConfidenceHalfWidth95())
+		} else {
+			printer.Printf("     Packs opened        %v\n", t.numOpened/completedRuns)
+		}
+		printer.Printf("     Rare packs          %v\n", t.numRarePacks/completedRuns)
+		printer.Printf("     Cards from pack pts %v\n", t.numCardsObtainedFromPackPoints/completedRuns)
+
+		missing, _ := userCollection.MissingSetForExpansion(e.Id())
+		analytic, _, aErr := sim.EstimateDuplicatesAnalytically(e, missing, t.duplicates.Packs())
+		if aErr != nil {
+			return aErr
+		}
+		printDuplicates(t.duplicates, analytic, runMode.resources)
+		if runMode.showCosts {
+			printCosts(e, t.packsOpened, runMode.costModel, runMode.costDays)
+		}
 	}
 	printer.Println()
 	printHeading2(printer.Sprintf("Total pack openings %d\n", averagesTotal))
 
+	if timeline != nil {
+		fmt.Println()
+		printTimeline(expansions, timeline)
+	}
+
+	return simErr
+}
+
+// Prints simulated duplicates alongside the analytic estimate for the same
+// number of packs.
+func printDuplicates(simulated *sim.DuplicateEstimate, analytic *sim.DuplicateEstimate, resources *data.ResourceTable) {
+	printer.Printf(
+		"     Duplicates          %.0f (analytically %.0f)\n",
+		simulated.ExpectedTotal(),
+		analytic.ExpectedTotal(),
+	)
+	for r := range data.Rarities.All() {
+		s, a := simulated.Expected(r), analytic.Expected(r)
+		if s == 0 && a == 0 {
+			continue
+		}
+		printer.Printf("       %-5v %10.1f %10.1f\n", r, s, a)
+	}
+
+	simulatedResources := simulated.Resources(resources)
+	analyticResources := analytic.Resources(resources)
+	for name := range resources.Names() {
+		printer.Printf(
+			"     As %-16v %.0f (analytically %.0f)\n",
+			name,
+			simulatedResources[name],
+			analyticResources[name],
+		)
+	}
+}
+
+var costPercentiles = []float64{50, 75, 90, 95}
+
+// Prints the cost of the packs opened at percentiles of the simulations.
+// Runs that ran out of the pack budget rank above the completed runs, so
+// percentiles among them are only known to be over budget.
+func printCosts(e *data.Expansion, packsOpened *sim.Distribution, model *data.CostModel, days uint) {
+	if days > 0 {
+		printer.Printf("     Estimated cost to complete %v within %d days (%v)\n", e.Name(), days, model.Currency())
+	} else {
+		printer.Printf("     Estimated cost to complete %v now (%v)\n", e.Name(), model.Currency())
+	}
+	if packsOpened.Censored() > 0 {
+		printer.Printf(
+			"     %d of %d runs ran out of the pack budget before completing\n",
+			packsOpened.Censored(),
+			packsOpened.Count(),
+		)
+	}
+	fmt.Println("             Packs   Bought  Hourglasses  Poké Gold      Price  With premium pass")
+	for _, percent := range costPercentiles {
+		packs, known := packsOpened.Percentile(percent)
+		if !known {
+			printer.Printf("       p%-3v  over the pack budget\n", percent)
+			continue
+		}
+		cost := model.Cost(packs, days, false)
+		// The pass only gives packs over days
+		premiumPrice := "-"
+		if model.PremiumPass() != nil && days > 0 {
+			premiumPrice = printer.Sprintf("%.2f", model.Cost(packs, days, true).Price())
+		}
+		printer.Printf(
+			"       p%-3v %7d  %7d  %11d  %9d  %9.2f  %17v\n",
+			percent,
+			packs,
+			cost.BoughtPacks(),
+			cost.Hourglasses(),
+			cost.PokeGold(),
+			cost.Price(),
+			premiumPrice,
+		)
+	}
+}
+
+func printTimeline(expansions []*data.Expansion, timeline *sim.Timeline) {
+	const slowestCardsShown = 10
+	for _, e := range expansions {
+		et := timeline.Expansion(e)
+		if et.MaxPacks() == 0 {
+			continue
+		}
+
+		printHeading2(fmt.Sprintf("%v acquisition timeline", e.Name()))
+		fmt.Println("     Completion curve")
+		for _, percent := range []float64{25, 50, 75, 90, 95, 100} {
+			if et.CompletionAfter(0) >= percent {
+				continue
+			}
+			packs, reached := et.PacksToReach(percent)
+			if reached {
+				printer.Printf("       %3.0f%% after %d packs\n", percent, packs)
+			}
+		}
+
+		// Slowest cards, plus every crown as those are what people wait for.
+		// Cards obtained in fewer runs are slower whatever their mean, which
+		// only covers the runs obtaining them.
+		cards := slices.Collect(et.Cards())
+		slices.SortStableFunc(cards, func(c1, c2 *sim.CardTimeline) int {
+			return cmp.Or(
+				cmp.Compare(c1.PacksUntil().Count(), c2.PacksUntil().Count()),
+				cmp.Compare(c2.PacksUntil().Mean(), c1.PacksUntil().Mean()),
+			)
+		})
+		fmt.Println("     Expected packs until obtained, in the runs obtaining the card")
+		for i, ct := range cards {
+			if i >= slowestCardsShown && !ct.Card().Rarity().IsCrown() {
+				continue
+			}
+			obtained := ct.PacksUntil().Count()
+			if obtained == 0 {
+				printer.Printf(
+					"       %v) %v %v: not obtained in any run\n",
+					ct.Card().Number(),
+					ct.Card().Rarity(),
+					ct.Card().Name(),
+				)
+				continue
+			}
+			printer.Printf(
+				"       %v) %v %v: %.0f ±%.0f, obtained in %.0f%% of runs (pack points in %.0f%%)\n",
+				ct.Card().Number(),
+				ct.Card().Rarity(),
+				ct.Card().Name(),
+				ct.PacksUntil().Mean(),
+				ct.PacksUntil().StdDev(),
+				100*float64(obtained)/float64(et.Runs()),
+				100*float64(ct.FromPackPoints())/float64(et.Runs()),
+			)
+		}
+	}
+}
+
+func budgetTargets(
+	spec string,
+	e *data.Expansion,
+	userData *userdata.UserData,
+) (*data.CardSet, error) {
+	switch spec {
+	case "all":
+		return e.AllCards(), nil
+	case "non-secret":
+		return e.NonSecretCards(), nil
+	}
+
+	if wishlistName, isWishlist := strings.CutPrefix(spec, "wishlist:"); isWishlist {
+		for w := range userData.Wishlists() {
+			if w.Name() != wishlistName {
+				continue
+			}
+			cards, _ := w.CardsForExpansion(e.Id())
+			return data.NewCardSet(cards...), nil
+		}
+		return nil, fmt.Errorf("no wishlist named '%v'", wishlistName)
+	}
+
+	for r := range data.Rarities.All() {
+		if r.String() != spec {
+			continue
+		}
+		targets := data.NewCardSet()
+		for c := range e.Cards() {
+			if c.Rarity() == r {
+				targets.Add(c)
+			}
+		}
+		return targets, nil
+	}
+	return nil, fmt.Errorf("unknown budget target '%v'", spec)
+}
+
+func formatExpectedMissing(estimate *sim.BudgetEstimate) string {
+	var byRarity []string
+	for r, m := range estimate.ExpectedMissingByRarity() {
+		byRarity = append(byRarity, fmt.Sprintf("%v %.2f", r, m))
+	}
+	return printer.Sprintf(
+		"%.1f%% chance, expected missing %.2f (%v)",
+		100*estimate.CompletionProbability(),
+		estimate.ExpectedMissingTotal(),
+		strings.Join(byRarity, " "),
+	)
+}
+
+func printBudgetEstimates(
+	ctx context.Context,
+	runMode *runOptions,
+	expansions []*data.Expansion,
+	userData *userdata.UserData,
+) error {
+	printHeading1(printer.Sprintf(
+		"Chance of completing '%v' within %d more packs",
+		runMode.budgetTarget,
+		runMode.budgetPacks,
+	))
+	fmt.Println("  Analytic numbers open only the best booster and don't use pack points.")
+	for _, e := range expansions {
+		if runMode.budgetExpansionId != "" && e.Id() != runMode.budgetExpansionId {
+			continue
+		}
+		missing, mFound := userData.Collection().MissingSetForExpansion(e.Id())
+		if !mFound {
+			continue
+		}
+		targets, tErr := budgetTargets(runMode.budgetTarget, e, userData)
+		if tErr != nil {
+			return tErr
+		}
+		if !missing.Intersects(targets) {
+			continue
+		}
+
+		analytic, booster, aErr := sim.EstimateBudgetAnalytically(e, missing, targets, runMode.budgetPacks)
+		if aErr != nil {
+			return aErr
+		}
+		simulated, sErr := sim.SimulateBudget(
+			e,
+			userData.Collection(),
+			targets,
+			runMode.budgetPacks,
+			runMode.simulationRuns,
+			runMode.randomSeed,
+			runMode.simulationWorkers,
+			ctx,
+		)
+		if sErr != nil {
+			return sErr
+		}
+
+		printHeading2(e.Name())
+		printer.Printf("     Simulated (%d runs)   %v\n", simulated.Runs(), formatExpectedMissing(simulated))
+		printer.Printf("     Analytic (%v) %v\n", booster.Name(), formatExpectedMissing(analytic))
+	}
 	return nil
 }
 
 type runOptions struct {
-	simulationRuns uint64
-	randomSeed     uint64
+	simOptions *sim.SimOptions
+	// Report the chance of completing budgetTarget within this many packs
+	// when > 0
+	budgetPacks       uint64
+	budgetExpansionId data.ExpansionId
+	budgetTarget      string
+	simulationRuns    uint64
+	simulationWorkers int
+	// Set when running until a precision is reached rather than a set
+	// number of runs
+	adaptiveOptions *sim.AdaptiveOptions
+	randomSeed      uint64
+	boosterOptions  *data.BoosterOptions
+	// Rank boosters, redemptions and sim booster choice by wishlist weights
+	weighted bool
+	// Deck lists to import as wishlists
+	deckFilepaths []string
+	// Age at which cached pages are revalidated, 0 for never
+	cacheMaxAge time.Duration
+	offline     bool
+	// serebii, embedded or a snapshot file
+	catalogueSource string
+	// Fail on Serebii rows that can't be parsed instead of skipping them
+	strictParsing bool
+	// Rarity definitions added to the built in ones
+	raritiesFilepath string
+	// Duplicate conversions replacing the built in ones
+	resourcesFilepath string
+	// Loaded after rarities, as conversions are by rarity
+	resources *data.ResourceTable
+	// Profile in the data file to use, empty when there's only one
+	profile string
+	// Rounds of packs between team trade sessions, 0 to never trade
+	teamTradeEvery uint64
+	// Cards each team member completes, all, non-secret or a rarity
+	teamTarget string
+	// Report the cost of completing each expansion's simulations
+	showCosts bool
+	// Days the packs are opened over, for free packs
+	costDays  uint
+	costModel *data.CostModel
 }
 
-func readRunOptions() (*runOptions, error) {
-	simRunsPointer := flag.Uint64("r", 10, "number of sim runs")
-	randomSeedPointer := flag.Uint64("s", rand.Uint64(), "sim random seed")
-	flag.Parse()
+func loadRarities(path string) error {
+	f, oErr := os.Open(path)
+	if oErr != nil {
+		return oErr
+	}
+	defer f.Close()
+	return data.Rarities.Load(f)
+}
 
-	return &runOptions{simulationRuns: *simRunsPointer, randomSeed: *randomSeedPointer}, nil
+func loadCostModel(path string) (*data.CostModel, error) {
+	if path == "" {
+		return data.DefaultCostModel(), nil
+	}
+	f, oErr := os.Open(path)
+	if oErr != nil {
+		return nil, oErr
+	}
+	defer f.Close()
+	return data.ReadCostModel(f)
 }
 
-func main() {
-	runMode, rErr := readRunOptions()
-	if rErr != nil {
-		panic(rErr)
+func loadResources(path string) (*data.ResourceTable, error) {
+	if path == "" {
+		return data.DefaultResourceTable(), nil
 	}
+	f, oErr := os.Open(path)
+	if oErr != nil {
+		return nil, oErr
+	}
+	defer f.Close()
+	return data.ReadResourceTable(f)
+}
+
+// Values of -catalogue that aren't snapshot files.
+const (
+	catalogueSerebii  = "serebii"
+	catalogueEmbedded = "embedded"
+)
 
-	// Gather data from sources
+// Parses every expansion's booster pages from Serebii, or the cache.
+func scrapeExpansions(
+	rootCtx context.Context,
+	cache *fetch.Cache,
+	boosterOptions *data.BoosterOptions,
+	strict bool,
+) ([]*data.Expansion, error) {
 	results := make(chan *data.Expansion, len(expansionDataSources))
-	g, ctx := errgroup.WithContext(context.Background())
+	g, ctx := errgroup.WithContext(rootCtx)
 	indexMap := make(map[data.ExpansionId]int)
 	for i, s := range expansionDataSources {
 		indexMap[s.Id()] = i
 		g.Go(func() error {
-			return serebii.FetchExpansionDetails(ctx, s, results)
+			return serebii.FetchExpansionDetails(ctx, cache, s, boosterOptions, strict, results)
 		})
 	}
 	err := g.Wait()
 	close(results)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	var expansions []*data.Expansion
@@ -709,58 +1307,753 @@ func main() {
 	slices.SortFunc(expansions, func(e1, e2 *data.Expansion) int {
 		return indexMap[e1.Id()] - indexMap[e2.Id()]
 	})
+	return expansions, nil
+}
+
+// Loads the catalogue from the source chosen with -catalogue.
+func loadExpansions(ctx context.Context, runMode *runOptions, cache *fetch.Cache) ([]*data.Expansion, error) {
+	var snapshot *catalogue.Snapshot
+	var sErr error
+	switch runMode.catalogueSource {
+	case catalogueSerebii:
+		return scrapeExpansions(ctx, cache, runMode.boosterOptions, runMode.strictParsing)
+	case catalogueEmbedded:
+		snapshot, sErr = catalogue.ReadEmbeddedSnapshot()
+	default:
+		snapshot, sErr = catalogue.ReadSnapshotFile(runMode.catalogueSource)
+	}
+	if sErr != nil {
+		return nil, fmt.Errorf("loading catalogue %v: %w", runMode.catalogueSource, sErr)
+	}
+	return snapshot.Expansions(runMode.boosterOptions)
+}
+
+func printSnapshotDiff(oldPath string, newPath string) error {
+	oldSnapshot, oErr := catalogue.ReadSnapshotFile(oldPath)
+	if oErr != nil {
+		return fmt.Errorf("%v: %w", oldPath, oErr)
+	}
+	newSnapshot, nErr := catalogue.ReadSnapshotFile(newPath)
+	if nErr != nil {
+		return fmt.Errorf("%v: %w", newPath, nErr)
+	}
+
+	printHeading1(fmt.Sprintf("Catalogue changes from %v to %v", oldPath, newPath))
+	changes := catalogue.DiffSnapshots(oldSnapshot, newSnapshot)
+	if len(changes) == 0 {
+		fmt.Println("  No changes")
+	}
+	for _, c := range changes {
+		fmt.Printf("  %v\n", c)
+	}
+	return nil
+}
+
+// Parses every cached booster page, reporting rows that can't be parsed,
+// without fetching anything.
+func printParseCheck(ctx context.Context, cache *fetch.Cache) error {
+	offlineCache := fetch.NewCache(cache.Dir(), nil, 0, true)
+	printHeading1(fmt.Sprintf("Parsing cached pages in %v", cache.Dir()))
+	var failed, checked int
+	for _, s := range expansionDataSources {
+		for b := range s.BoosterSources() {
+			body, gErr := offlineCache.Get(ctx, b.SerebiiUrl())
+			if errors.Is(gErr, fetch.ErrOffline) {
+				fmt.Printf("  %v - %v: not cached\n", s.Name(), b.Name())
+				continue
+			}
+			if gErr != nil {
+				return gErr
+			}
+			checked++
+
+			page, pErr := serebii.ParseBoosterPage(b.SerebiiUrl(), body, false)
+			if pErr != nil {
+				failed++
+				fmt.Printf("  %v - %v: failed, %v\n", s.Name(), b.Name(), pErr)
+				continue
+			}
+			if len(page.Warnings()) > 0 {
+				failed++
+			}
+			fmt.Printf("  %v - %v: %v cards, %v warnings\n", s.Name(), b.Name(), len(page.Cards()), len(page.Warnings()))
+			for _, w := range page.Warnings() {
+				fmt.Printf("    %v\n", w)
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%v of %v cached pages didn't parse cleanly", failed, checked)
+	}
+	return nil
+}
+
+// Commands run once the catalogue is loaded.
+var catalogueCommands = []string{"export", "import", "redeem", "compare", "trades", "team"}
+
+// Number of cards shown per expansion in the redeem report.
+const redemptionsShown = 10
+
+func printRedemptionAdvice(expansions []*data.Expansion, userData *userdata.UserData) {
+	printHeading1("Pack point redemptions")
+	wishlists := slices.Collect(userData.Wishlists())
+	for _, e := range expansions {
+		eCollection := userData.Collection().GetExpansionCollection(e.Id())
+		if eCollection == nil || eCollection.Missing().IsEmpty() {
+			continue
+		}
+		weights := userdata.MergeWishlistWeights(wishlists, e.Id(), eCollection.Missing())
+		options := sim.RankRedemptions(e, eCollection, weights)
+
+		printHeading2(fmt.Sprintf("%v (%v pack points)", e.Name(), eCollection.PackPoints()))
+		for i, o := range options {
+			if i >= redemptionsShown {
+				break
+			}
+			c := o.Card()
+			advice := o.Advice().String()
+			if o.Advice() == sim.SaveUp {
+				advice = printer.Sprintf("%v, %d more packs", advice, o.PacksToAfford())
+			}
+			source := "only by redeeming"
+			if b, bFound := o.Booster(); bFound {
+				source = printer.Sprintf(
+					"1 in %.0f packs of %v, %.0f%% chance while earning its cost",
+					o.ExpectedPacksToPull(),
+					b.Name(),
+					100*o.PullChanceWhileEarning(),
+				)
+			}
+			printer.Printf(
+				"    %v) %v %v (weight %v): %v\n       %.3f packs saved per point for %v points, %v\n",
+				c.Number(),
+				c.Rarity(),
+				c.Name(),
+				o.Weight(),
+				advice,
+				o.PacksSavedPerPoint(),
+				c.Rarity().PackPointsToObtain(),
+				source,
+			)
+		}
+	}
+}
+
+// Prints each profile's missing cards by rarity for every expansion.
+func printProfileComparison(expansions []*data.Expansion, profiles *userdata.Profiles) {
+	printHeading1("Missing cards by profile")
+	names := slices.Collect(profiles.Names())
+	for _, e := range expansions {
+		printHeading2(e.Name())
+		fmt.Printf("     %-6v", "")
+		for _, n := range names {
+			fmt.Printf(" %10v", n)
+		}
+		fmt.Println()
+		for r := range data.Rarities.All() {
+			ranked := profiles.RankByMissing(e, r)
+			if len(ranked) == 0 || ranked[0].Missing() == 0 {
+				continue
+			}
+			missingByName := make(map[string]int, len(ranked))
+			for _, m := range ranked {
+				missingByName[m.Name()] = m.Missing()
+			}
+			fmt.Printf("     %-6v", r)
+			for _, n := range names {
+				missing, mFound := missingByName[n]
+				if !mFound {
+					fmt.Printf(" %10v", "-")
+					continue
+				}
+				fmt.Printf(" %10v", missing)
+			}
+			fmt.Println()
+		}
+	}
+}
+
+// Prints profiles by who has the most missing cards of the rarity in the
+// expansion.
+func printProfileRanking(
+	expansions []*data.Expansion,
+	profiles *userdata.Profiles,
+	expansionId data.ExpansionId,
+	raritySpec string,
+) error {
+	eIndex := slices.IndexFunc(expansions, func(e *data.Expansion) bool {
+		return e.Id() == expansionId
+	})
+	if eIndex == -1 {
+		return fmt.Errorf("unknown expansion '%v'", expansionId)
+	}
+	e := expansions[eIndex]
+	rarity, rErr := data.Rarities.BySymbol(raritySpec)
+	if rErr != nil {
+		var nErr error
+		if rarity, nErr = data.Rarities.ByName(raritySpec); nErr != nil {
+			return rErr
+		}
+	}
+
+	printHeading1(fmt.Sprintf("Most missing %v cards from %v", rarity, e.Name()))
+	for i, m := range profiles.RankByMissing(e, rarity) {
+		fmt.Printf("  %v) %v: %v\n", i+1, m.Name(), m.Missing())
+	}
+	return nil
+}
+
+// Prints the trades matched between every pair of profiles.
+func printTrades(expansions []*data.Expansion, profiles *userdata.Profiles) {
+	trades := trade.MatchTrades(expansions, profiles)
+	printHeading1(fmt.Sprintf("Trades (%v, completing %v cards)", len(trades), 2*len(trades)))
+	if len(trades) == 0 {
+		fmt.Println("  No trades found. Duplicates are only known when recorded in the data file.")
+		return
+	}
+
+	var pair string
+	for _, t := range trades {
+		if p := t.Player1() + " and " + t.Player2(); p != pair {
+			pair = p
+			printHeading2(pair)
+		}
+		fmt.Printf("     %v gives %v\n", t.Player1(), t.Card1())
+		fmt.Printf("       for %v\n", t.Card2())
+	}
+}
+
+type teamPlayerAmounts struct {
+	name           string
+	teamPacks      sim.RunningStat
+	soloPacks      sim.RunningStat
+	tradesReceived sim.RunningStat
+}
+
+type teamExpansionAmounts struct {
+	// In profile name order
+	players   []*teamPlayerAmounts
+	teamGroup sim.RunningStat
+	soloGroup sim.RunningStat
+	teamTotal sim.RunningStat
+	soloTotal sim.RunningStat
+}
+
+// Simulates the profiles opening packs together and trading, compared to
+// each opening on their own.
+func printTeamSimulations(
+	ctx context.Context,
+	runMode *runOptions,
+	expansions []*data.Expansion,
+	profiles *userdata.Profiles,
+) error {
+	if profiles.Len() < 2 {
+		return errors.New("team simulations need at least 2 profiles")
+	}
+	if strings.HasPrefix(runMode.teamTarget, "wishlist:") {
+		return errors.New("team simulations can't target a wishlist, as each profile has their own")
+	}
+	targets := make(map[*data.Expansion]*data.CardSet, len(expansions))
+	for _, e := range expansions {
+		t, tErr := budgetTargets(runMode.teamTarget, e, nil)
+		if tErr != nil {
+			return tErr
+		}
+		targets[e] = t
+	}
+
+	printHeading1(printer.Sprintf(
+		"Team pack opening simulations (%d runs, %v target, trading every %d packs)",
+		runMode.simulationRuns,
+		runMode.teamTarget,
+		runMode.teamTradeEvery,
+	))
+	fmt.Printf("  Seed: %v (%v sampling)\n", runMode.randomSeed, runMode.boosterOptions.SamplingMethod())
+	fmt.Println("  Each player opens a pack a round, trading duplicates one for one of the same rarity.")
+
+	results := make(chan *sim.TeamSimRun, runtime.GOMAXPROCS(0))
+	var simErr error
+	progress := &simProgress{runs: runMode.simulationRuns}
+	go func() {
+		simErr = sim.RunAllTeamSimulations(
+			expansions,
+			profiles,
+			func(e *data.Expansion, c *userdata.ExpansionCollection) bool {
+				return !c.Missing().Intersects(targets[e])
+			},
+			runMode.simOptions,
+			runMode.teamTradeEvery,
+			runMode.simulationRuns,
+			runMode.randomSeed,
+			runMode.simulationWorkers,
+			ctx,
+			results,
+		)
+		close(results)
+	}()
+
+	amounts := make(map[*data.Expansion]*teamExpansionAmounts, len(expansions))
+	for r := range results {
+		progress.increment()
+		for eRun := range r.ExpansionRuns() {
+			eAmounts := amounts[eRun.Expansion()]
+			if eAmounts == nil {
+				eAmounts = &teamExpansionAmounts{}
+				amounts[eRun.Expansion()] = eAmounts
+			}
+			var teamTotal, soloTotal uint64
+			for i, p := range slices.Collect(eRun.Players()) {
+				if i == len(eAmounts.players) {
+					eAmounts.players = append(eAmounts.players, &teamPlayerAmounts{name: p.Name()})
+				}
+				pAmounts := eAmounts.players[i]
+				pAmounts.teamPacks.Add(float64(p.TeamPacks()))
+				pAmounts.soloPacks.Add(float64(p.SoloPacks()))
+				pAmounts.tradesReceived.Add(float64(p.TradesReceived()))
+				teamTotal += p.TeamPacks()
+				soloTotal += p.SoloPacks()
+			}
+			eAmounts.teamGroup.Add(float64(eRun.TeamGroupPacks()))
+			eAmounts.soloGroup.Add(float64(eRun.SoloGroupPacks()))
+			eAmounts.teamTotal.Add(float64(teamTotal))
+			eAmounts.soloTotal.Add(float64(soloTotal))
+		}
+	}
+	if simErr != nil {
+		if !errors.Is(simErr, context.Canceled) {
+			return simErr
+		}
+		fmt.Fprintln(os.Stderr)
+		fmt.Println("  Interrupted, showing partial results")
+	}
+
+	fmt.Println()
+	for _, e := range expansions {
+		eAmounts, aFound := amounts[e]
+		if !aFound || eAmounts.teamGroup.Count() == 0 {
+			continue
+		}
+		printHeading2(e.Name())
+		fmt.Println("     Packs to complete        Solo     Team   Saved   Trades")
+		for _, p := range eAmounts.players {
+			printTeamPacks(p.name, &p.soloPacks, &p.teamPacks, p.tradesReceived.Mean())
+		}
+		printTeamPacks("Last to complete", &eAmounts.soloGroup, &eAmounts.teamGroup, -1)
+		printTeamPacks("Team total", &eAmounts.soloTotal, &eAmounts.teamTotal, -1)
+	}
+	return nil
+}
+
+// Prints mean packs solo and in the team, with mean trades received unless
+// negative.
+func printTeamPacks(name string, solo *sim.RunningStat, team *sim.RunningStat, trades float64) {
+	saved := 0.0
+	if solo.Mean() > 0 {
+		saved = 100 * (solo.Mean() - team.Mean()) / solo.Mean()
+	}
+	line := printer.Sprintf("       %-18v %8.0f %8.0f %6.1f%%", name, solo.Mean(), team.Mean(), saved)
+	if trades >= 0 {
+		line += printer.Sprintf(" %8.1f", trades)
+	}
+	fmt.Println(line)
+}
+
+// Imports a collection tracker export into a new data file, printing the
+// rows that couldn't be matched.
+func importCollection(expansions []*data.Expansion, exportFilepath string, dataFilepath string) error {
+	format, fErr := userdata.ImportFormatForFilename(exportFilepath)
+	if fErr != nil {
+		return fErr
+	}
+	export, oErr := os.Open(exportFilepath)
+	if oErr != nil {
+		return oErr
+	}
+	defer export.Close()
+	collection, report, iErr := userdata.ImportCollection(export, format, expansions)
+	if iErr != nil {
+		return fmt.Errorf("%v: %w", exportFilepath, iErr)
+	}
+
+	out, cErr := os.OpenFile(dataFilepath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if cErr != nil {
+		return cErr
+	}
+	if wErr := userdata.WriteCollection(out, collection); wErr != nil {
+		out.Close()
+		return wErr
+	}
+	if cErr := out.Close(); cErr != nil {
+		return cErr
+	}
+
+	printHeading1(fmt.Sprintf("Imported %v to %v", exportFilepath, dataFilepath))
+	fmt.Printf("  Matched %v of %v rows\n", report.Matched(), report.Rows())
+	issues := slices.Collect(report.Issues())
+	if len(issues) > 0 {
+		printHeading2(fmt.Sprintf("Rows to check (%v)", len(issues)))
+		for _, i := range issues {
+			fmt.Printf("     %v\n", i)
+		}
+	}
+	unlisted := slices.Collect(report.Unlisted())
+	if len(unlisted) > 0 {
+		printHeading2("Expansions not in the export, imported as entirely missing")
+		for _, e := range unlisted {
+			fmt.Printf("     %v (%v)\n", e.Name(), e.Code())
+		}
+	}
+	fmt.Println("  Pack points aren't exported, set them in the data file.")
+	return nil
+}
+
+func runCatalogueCommand(ctx context.Context, args []string, expansions []*data.Expansion, runMode *runOptions) error {
+	profile := runMode.profile
+	switch {
+	case args[0] == "export" && len(args) == 2:
+		if wErr := catalogue.NewSnapshot(expansions).WriteFile(args[1]); wErr != nil {
+			return wErr
+		}
+		fmt.Printf("Exported the catalogue to %v\n", args[1])
+		return nil
+	case args[0] == "redeem" && len(args) == 1:
+		userData, uErr := readUserData(expansions, profile)
+		if uErr != nil {
+			return uErr
+		}
+		printRedemptionAdvice(expansions, userData)
+		return nil
+	case args[0] == "compare" && (len(args) == 1 || len(args) == 3):
+		profiles, pErr := readProfiles(expansions)
+		if pErr != nil {
+			return pErr
+		}
+		if len(args) == 3 {
+			return printProfileRanking(expansions, profiles, data.ExpansionId(args[1]), args[2])
+		}
+		printProfileComparison(expansions, profiles)
+		return nil
+	case args[0] == "trades" && len(args) == 1:
+		profiles, pErr := readProfiles(expansions)
+		if pErr != nil {
+			return pErr
+		}
+		printTrades(expansions, profiles)
+		return nil
+	case args[0] == "team" && len(args) == 1:
+		profiles, pErr := readProfiles(expansions)
+		if pErr != nil {
+			return pErr
+		}
+		return printTeamSimulations(ctx, runMode, expansions, profiles)
+	case args[0] == "import" && len(args) == 3:
+		return importCollection(expansions, args[1], args[2])
+	}
+	return fmt.Errorf(
+		"unknown command '%v', expected export <file>, import <export> <data file>, redeem, compare [<expansion> <rarity>], trades or team",
+		strings.Join(args, " "),
+	)
+}
+
+// Runs a command given after the flags that doesn't need the catalogue,
+// instead of the reports.
+func runCommand(ctx context.Context, cache *fetch.Cache, args []string) error {
+	if args[0] == "diff" && len(args) == 3 {
+		return printSnapshotDiff(args[1], args[2])
+	}
+	if args[0] == "parse-check" && len(args) == 1 {
+		return printParseCheck(ctx, cache)
+	}
+	if args[0] != "cache" || len(args) != 2 {
+		return fmt.Errorf(
+			"unknown command '%v', expected cache list|refresh|clear, export <file>, diff <old> <new>, parse-check, import, redeem, compare, trades or team",
+			strings.Join(args, " "),
+		)
+	}
+
+	switch args[1] {
+	case "list":
+		entries, eErr := cache.Entries()
+		if eErr != nil {
+			return eErr
+		}
+		printHeading1(fmt.Sprintf("Cached pages in %v", cache.Dir()))
+		for _, e := range entries {
+			status := "ok"
+			if !e.ChecksumValid() {
+				status = "checksum mismatch"
+			} else if !e.HasMetadata() {
+				status = "no metadata"
+			}
+			source := e.Url()
+			if source == "" {
+				source, _ = filepath.Rel(cache.Dir(), e.Path())
+			}
+			printer.Printf(
+				"  %v\n     fetched %v (%v ago), %d bytes, sha256 %.12v, %v\n",
+				source,
+				e.FetchedAt().Local().Format(time.DateTime),
+				time.Since(e.FetchedAt()).Round(time.Minute),
+				e.Size(),
+				e.Checksum(),
+				status,
+			)
+		}
+		return nil
+	case "refresh":
+		printHeading1("Refreshing cached pages")
+		for _, s := range expansionDataSources {
+			for b := range s.BoosterSources() {
+				changed, rErr := cache.Refresh(ctx, b.SerebiiUrl())
+				if rErr != nil {
+					return rErr
+				}
+				outcome := "unchanged"
+				if changed {
+					outcome = "updated"
+				}
+				fmt.Printf("  %v - %v: %v\n", s.Name(), b.Name(), outcome)
+			}
+		}
+		return nil
+	case "clear":
+		if cErr := cache.Clear(); cErr != nil {
+			return cErr
+		}
+		fmt.Printf("Cleared %v\n", cache.Dir())
+		return nil
+	}
+	return fmt.Errorf("unknown cache command '%v', expected list, refresh or clear", args[1])
+}
+
+func readRunOptions() (*runOptions, error) {
+	simRunsPointer := flag.Uint64("r", 10, "number of sim runs")
+	simWorkersPointer := flag.Int("w", 0, "number of sim workers (defaults to the number of CPUs)")
+	randomSeedPointer := flag.Uint64("s", rand.Uint64(), "sim random seed")
+	ciPointer := flag.Float64("ci", 0, "run sims until the 95% confidence interval of packs opened is within ± this many packs, instead of -r runs")
+	ciTimePointer := flag.Duration("ci-time", time.Minute, "time budget for -ci simulations")
+	timelinePointer := flag.Bool("timeline", false, "record and report when each missing card is obtained in simulations")
+	budgetPointer := flag.Uint64("budget", 0, "report the chance of completing -budget-target within this many more packs per expansion")
+	budgetExpansionPointer := flag.String("budget-expansion", "", "only report -budget for this expansion id")
+	budgetTargetPointer := flag.String(
+		"budget-target",
+		"all",
+		"cards -budget reports on: all, non-secret, a rarity (e.g. ☆☆) or wishlist:<name>",
+	)
+	ciBatchPointer := flag.Uint64("ci-batch", 100, "number of runs between -ci precision checks")
+	offeringDriftPointer := flag.String(
+		"offering-drift",
+		data.OfferingDriftRenormalise.String(),
+		"what to do when booster offerings don't total 100% (renormalise, as-is, fail)",
+	)
+	samplingPointer := flag.String(
+		"sampling",
+		data.SamplingAlias.String(),
+		"how cards are drawn from boosters (alias, binary, linear)",
+	)
+	cacheMaxAgePointer := flag.Duration("cache-max-age", 7*24*time.Hour, "revalidate cached pages older than this, 0 to never")
+	offlinePointer := flag.Bool("offline", false, "only use cached pages, failing if one isn't cached")
+	defaultCatalogue := catalogueSerebii
+	if catalogue.HasEmbeddedSnapshot() {
+		defaultCatalogue = catalogueEmbedded
+	}
+	raritiesPointer := flag.String("rarities", "", "JSON file of rarities to add to or redefine the built in ones")
+	resourcesPointer := flag.String("resources", "", "JSON file of what duplicates convert into, replacing the built in table")
+	profilePointer := flag.String("profile", "", "profile in data.json or the profiles directory to use")
+	teamTradeEveryPointer := flag.Uint64("team-trade-every", 14, "packs each player opens between team trade sessions, 0 to never trade")
+	teamTargetPointer := flag.String("team-target", "non-secret", "cards each player completes in team simulations: all, non-secret or a rarity")
+	costPointer := flag.Bool("cost", false, "report the cost of the packs each simulation opened")
+	costDaysPointer := flag.Uint("cost-days", 0, "days to complete in for -cost, with free packs opened each day")
+	costsPointer := flag.String("costs", "", "JSON file of pack and Poké Gold prices, replacing the built in ones")
+	strictPointer := flag.Bool("strict", false, "fail on any Serebii parse warning, not only cards that can't be parsed")
+	cataloguePointer := flag.String(
+		"catalogue",
+		defaultCatalogue,
+		"where to load the catalogue from: serebii, embedded or an exported snapshot file",
+	)
+	var deckFilepaths stringsFlag
+	flag.Var(&deckFilepaths, "deck", "deck list file to import as a wishlist of missing copies, can be repeated")
+	weightedPointer := flag.Bool("weighted", false, "score boosters and pack point redemptions by wishlist priorities")
+	flag.Parse()
+
+	offeringDriftPolicy, pErr := data.ParseOfferingDriftPolicy(*offeringDriftPointer)
+	if pErr != nil {
+		return nil, pErr
+	}
+	samplingMethod, sErr := data.ParseSamplingMethod(*samplingPointer)
+	if sErr != nil {
+		return nil, sErr
+	}
+
+	costModel, cErr := loadCostModel(*costsPointer)
+	if cErr != nil {
+		return nil, cErr
+	}
+
+	var adaptiveOptions *sim.AdaptiveOptions
+	if *ciPointer > 0 {
+		adaptiveOptions = sim.NewAdaptiveOptions(*ciPointer, *ciBatchPointer, *ciTimePointer)
+	}
+
+	return &runOptions{
+		simOptions:        sim.NewSimOptions(*timelinePointer, 0, nil),
+		budgetPacks:       *budgetPointer,
+		budgetExpansionId: *budgetExpansionPointer,
+		budgetTarget:      *budgetTargetPointer,
+		simulationRuns:    *simRunsPointer,
+		adaptiveOptions:   adaptiveOptions,
+		simulationWorkers: *simWorkersPointer,
+		randomSeed:        *randomSeedPointer,
+		boosterOptions:    data.NewBoosterOptions(offeringDriftPolicy, samplingMethod),
+		weighted:          *weightedPointer,
+		deckFilepaths:     deckFilepaths,
+		cacheMaxAge:       *cacheMaxAgePointer,
+		offline:           *offlinePointer,
+		catalogueSource:   *cataloguePointer,
+		strictParsing:     *strictPointer,
+		raritiesFilepath:  *raritiesPointer,
+		resourcesFilepath: *resourcesPointer,
+		profile:           *profilePointer,
+		teamTradeEvery:    *teamTradeEveryPointer,
+		teamTarget:        *teamTargetPointer,
+		showCosts:         *costPointer,
+		costDays:          *costDaysPointer,
+		costModel:         costModel,
+	}, nil
+}
+
+func main() {
+	runMode, rErr := readRunOptions()
+	if rErr != nil {
+		panic(rErr)
+	}
+	if runMode.raritiesFilepath != "" {
+		if lErr := loadRarities(runMode.raritiesFilepath); lErr != nil {
+			panic(lErr)
+		}
+	}
+	resources, lErr := loadResources(runMode.resourcesFilepath)
+	if lErr != nil {
+		panic(lErr)
+	}
+	runMode.resources = resources
+
+	// Ctrl-C stops simulations early, still reporting what has completed
+	rootCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	dir, dErr := os.Getwd()
+	if dErr != nil {
+		panic(dErr)
+	}
+	cache := fetch.NewCache(
+		filepath.Join(dir, ".cache"),
+		fetch.NewFetcher(&http.Client{}, fetch.DefaultOptions),
+		runMode.cacheMaxAge,
+		runMode.offline,
+	)
+
+	runsOnCatalogue := flag.NArg() > 0 && slices.Contains(catalogueCommands, flag.Arg(0))
+	if flag.NArg() > 0 && !runsOnCatalogue {
+		if cErr := runCommand(rootCtx, cache, flag.Args()); cErr != nil {
+			panic(cErr)
+		}
+		return
+	}
+
+	expansions, eErr := loadExpansions(rootCtx, runMode, cache)
+	if eErr != nil {
+		panic(eErr)
+	}
+
+	if runsOnCatalogue {
+		if cErr := runCatalogueCommand(rootCtx, flag.Args(), expansions, runMode); cErr != nil {
+			panic(cErr)
+		}
+		return
+	}
 
 	// Loading collection
-	userData, uErr := readUserData(expansions)
+	userData, uErr := readUserData(expansions, runMode.profile)
 	if uErr != nil {
 		panic(uErr)
 	}
 
-	printBoosterDataAudit(expansions)
+	printBoosterDataAudit(expansions, runMode.boosterOptions.OfferingDriftPolicy())
 	fmt.Println()
 
 	printCurrentCollectionStats(expansions, userData.Collection())
 	fmt.Println()
 
+	for _, deckFilepath := range runMode.deckFilepaths {
+		d, wishlist, dErr := importDeck(deckFilepath, expansions, userData.Collection())
+		if dErr != nil {
+			panic(dErr)
+		}
+		printDeckImport(d, userData.Collection())
+		fmt.Println()
+		userData.AddWishlist(wishlist)
+	}
+
 	for w := range userData.Wishlists() {
 		printHeading1(fmt.Sprintf("Wishlist '%v' cards", w.Name()))
 		for _, e := range expansions {
 			cards, cFound := w.CardsForExpansion(e.Id())
 			if cFound {
+				weights, _ := w.WeightsForExpansion(e.Id())
 				printHeading2(e.Name())
 				for _, c := range cards {
-					fmt.Printf("    %v) %v %v\n", c.Number(), c.Rarity(), c.Name())
+					copies := ""
+					if n := w.CopiesWanted(e.Id(), c); n > 1 {
+						copies = fmt.Sprintf(" x%v", n)
+					}
+					fmt.Printf("    %v) %v %v%v (weight %v)\n", c.Number(), c.Rarity(), c.Name(), copies, weights[c.Number()])
 				}
 			}
 		}
 		fmt.Println()
 
+		if runMode.weighted {
+			printWeightedBoosterScores(
+				fmt.Sprintf("Collection + wishlist '%v' weighted booster scores", w.Name()),
+				func(e *data.Expansion) (*data.CardSet, data.CardWeights, bool) {
+					cards, f1 := w.CardsForExpansion(e.Id())
+					missing, f2 := userData.Collection().MissingSetForExpansion(e.Id())
+					if !f1 && !f2 {
+						return nil, nil, false
+					}
+					targets := data.NewCardSet(cards...)
+					if f2 {
+						targets = targets.Union(missing)
+					}
+					return targets, w.WeightsWithCollection(e.Id(), missing), true
+				},
+				expansions,
+			)
+			fmt.Println()
+
+			printPackPointSuggestions(w, expansions, userData.Collection())
+			fmt.Println()
+			continue
+		}
+
 		printBoosterProbabilities(
 			fmt.Sprintf("Collection + wishlist '%v' booster probabilities", w.Name()),
-			func(e *data.Expansion) ([]*data.Card, bool) {
+			func(e *data.Expansion) (*data.CardSet, bool) {
 				cards1, f1 := w.CardsForExpansion(e.Id())
-				cards2, f2 := userData.Collection().MissingForExpansion(e.Id())
+				cards2, f2 := userData.Collection().MissingSetForExpansion(e.Id())
 				if !f1 && !f2 {
-					return cards1, false
+					return nil, false
 				}
 				if !f1 {
 					return cards2, true
 				}
-				if !f2 {
-					return cards1, true
-				}
 
-				allCards := append(cards1, cards2...)
-				// struct{} takes up 0 bytes
-				seen := make(map[*data.Card]struct{})
-				var uniqueCards []*data.Card
-				for _, c := range allCards {
-					if _, exists := seen[c]; !exists {
-						seen[c] = struct{}{}
-						uniqueCards = append(uniqueCards, c)
-					}
+				wishlistSet := data.NewCardSet(cards1...)
+				if !f2 {
+					return wishlistSet, true
 				}
-				return uniqueCards, true
+				return wishlistSet.Union(cards2), true
 			},
 			expansions,
 		)
@@ -769,38 +2062,85 @@ func main() {
 
 	printBoosterProbabilities(
 		"Collection booster probabilities",
-		func(e *data.Expansion) ([]*data.Card, bool) {
-			return userData.Collection().MissingForExpansion(e.Id())
+		func(e *data.Expansion) (*data.CardSet, bool) {
+			return userData.Collection().MissingSetForExpansion(e.Id())
 		},
 		expansions,
 	)
 	fmt.Println()
 
-	runSimulations(
+	if runMode.budgetPacks > 0 {
+		bErr := printBudgetEstimates(rootCtx, runMode, expansions, userData)
+		if errors.Is(bErr, context.Canceled) {
+			return
+		}
+		if bErr != nil {
+			panic(bErr)
+		}
+		fmt.Println()
+	}
+
+	if runMode.weighted {
+		runMode.simOptions = runMode.simOptions.WithBoosterWeights(wishlistBoosterWeights(expansions, userData))
+	}
+
+	wErr := runSimulations(
+		rootCtx,
 		"Whole collection",
 		runMode,
 		expansions,
 		userData.Collection(),
-		func(e *data.Expansion, m []*data.Card) bool {
-			return len(m) == 0
+		func(e *data.Expansion, c *userdata.ExpansionCollection) bool {
+			return c.Missing().IsEmpty()
 		},
 	)
+	if errors.Is(wErr, context.Canceled) {
+		return
+	}
+	if wErr != nil {
+		panic(wErr)
+	}
 	fmt.Println()
 
-	runSimulations(
+	nErr := runSimulations(
+		rootCtx,
 		"Non-secret cards collection",
 		runMode,
 		expansions,
 		userData.Collection(),
-		func(e *data.Expansion, m []*data.Card) bool {
-			for _, card := range m {
-				if !card.Rarity().IsSecret() {
-					return false
-				}
-			}
-			return true
+		func(e *data.Expansion, c *userdata.ExpansionCollection) bool {
+			return !e.HasAnyNonSecret(c.Missing())
 		},
 	)
+	if errors.Is(nErr, context.Canceled) {
+		return
+	}
+	if nErr != nil {
+		panic(nErr)
+	}
+
+	for w := range userData.Wishlists() {
+		fmt.Println()
+		// Booster choice steered by the wishlist's cards
+		wishlistRunMode := *runMode
+		wishlistRunMode.simOptions = runMode.simOptions.
+			WithBoosterWeights(sim.WishlistBoosterWeights(expansions, w)).
+			WithWishlist(w)
+		wlErr := runSimulations(
+			rootCtx,
+			fmt.Sprintf("Wishlist '%v'", w.Name()),
+			&wishlistRunMode,
+			expansions,
+			userData.Collection(),
+			sim.NewWishlistCompletePredicate(w),
+		)
+		if errors.Is(wlErr, context.Canceled) {
+			return
+		}
+		if wlErr != nil {
+			panic(wlErr)
+		}
+	}
 
 	// Custom query
 	// baseCardsSet := make(map[*data.BaseCard]struct{})
@@ -0,0 +1,409 @@
+package serebii
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"ptcgpocket/data"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Card numbers are shown as "<number> / <expansion total>".
+var cardNumberPattern = regexp.MustCompile("([0-9]+?) / ([0-9]+)")
+
+// Image names like a rarity's but not one we know, e.g. a new diamond5.
+var rarityImageNamePattern = regexp.MustCompile("^(diamond|star|shiny|crown)[0-9]*$")
+
+// Longest row HTML shown with a warning.
+const maxSnippetLength = 160
+
+// A problem parsing one row of a booster page.
+type ParseWarning struct {
+	url     string
+	row     int
+	snippet string
+	message string
+}
+
+func (w *ParseWarning) Url() string {
+	return w.url
+}
+
+// Index of the row in the page's card table, from 0 after the header.
+func (w *ParseWarning) Row() int {
+	return w.row
+}
+
+// The start of the row's HTML.
+func (w *ParseWarning) Snippet() string {
+	return w.snippet
+}
+
+func (w *ParseWarning) Message() string {
+	return w.message
+}
+
+func (w *ParseWarning) String() string {
+	return fmt.Sprintf("%v row %v: %v\n    %v", w.url, w.row, w.message, w.snippet)
+}
+
+// The cards parsed from a booster page and any problems with rows.
+type ParsedPage struct {
+	cards    []*data.Card
+	warnings []*ParseWarning
+}
+
+func (p *ParsedPage) Cards() []*data.Card {
+	return p.cards
+}
+
+func (p *ParsedPage) Warnings() []*ParseWarning {
+	return p.warnings
+}
+
+func getOnlyDexTable(doc *html.Node) (*html.Node, error) {
+	for d := range doc.Descendants() {
+		if d.DataAtom == atom.Table {
+			for _, a := range d.Attr {
+				if a.Key == "class" && a.Val == "dextable" {
+					return d, nil
+				}
+			}
+		}
+	}
+	return nil, errors.New("no dextable found")
+}
+
+func getImmediateRows(table *html.Node) []*html.Node {
+	rows := []*html.Node{}
+
+	var tbody *html.Node
+	for n := range table.ChildNodes() {
+		if n.DataAtom == atom.Tbody {
+			tbody = n
+		}
+	}
+	if tbody == nil {
+		return rows
+	}
+
+	i := 0
+	for d := range tbody.ChildNodes() {
+		if d.DataAtom == atom.Tr {
+			if i != 0 {
+				rows = append(rows, d)
+			}
+			i += 1
+		}
+	}
+	return rows
+}
+
+func extractNodeText(node *html.Node, joiner string) string {
+	var components []string
+	for c := range node.Descendants() {
+		if c.DataAtom == 0 {
+			comp := strings.TrimSpace(c.Data)
+			if comp != "" {
+				components = append(components, comp)
+			}
+		}
+	}
+	return strings.Join(components, joiner)
+}
+
+func childElements(node *html.Node, a atom.Atom) []*html.Node {
+	var elements []*html.Node
+	for c := range node.ChildNodes() {
+		if c.DataAtom == a {
+			elements = append(elements, c)
+		}
+	}
+	return elements
+}
+
+// The image's file name without its extension, e.g. diamond1.
+func imageName(img *html.Node) (string, bool) {
+	i := slices.IndexFunc(img.Attr, func(a html.Attribute) bool {
+		return a.Key == "src"
+	})
+	if i == -1 {
+		return "", false
+	}
+	comps := strings.Split(img.Attr[i].Val, "/")
+	return strings.Split(comps[len(comps)-1], ".")[0], true
+}
+
+func snippet(node *html.Node) string {
+	var buf bytes.Buffer
+	if err := html.Render(&buf, node); err != nil {
+		return ""
+	}
+	collapsed := strings.Join(strings.Fields(buf.String()), " ")
+	if runes := []rune(collapsed); len(runes) > maxSnippetLength {
+		return string(runes[:maxSnippetLength]) + "…"
+	}
+	return collapsed
+}
+
+// Reads the energy type from the first type icon in the row, and the stage
+// from its text.
+func parsePokemonTypeAndStage(row *html.Node) (data.EnergyType, data.Stage) {
+	energyType := data.EnergyTypeUnknown
+	stage := data.StageUnknown
+	for d := range row.Descendants() {
+		if d.DataAtom == atom.Img && energyType == data.EnergyTypeUnknown {
+			if name, nFound := imageName(d); nFound {
+				if t, tErr := data.ParseEnergyType(name); tErr == nil {
+					energyType = t
+				}
+			}
+		}
+		if d.DataAtom == 0 && stage == data.StageUnknown {
+			for _, s := range []data.Stage{data.StageTwo, data.StageOne, data.StageBasic} {
+				if strings.Contains(d.Data, s.String()) {
+					stage = s
+					break
+				}
+			}
+		}
+	}
+	return energyType, stage
+}
+
+// Parses a card row, collecting problems that don't stop the card being used.
+type rowParser struct {
+	row      *html.Node
+	warnings []string
+}
+
+func (p *rowParser) warn(format string, args ...any) {
+	p.warnings = append(p.warnings, fmt.Sprintf(format, args...))
+}
+
+// A nil card without an error is a row that isn't a card.
+func (p *rowParser) parse() (*data.Card, error) {
+	cells := childElements(p.row, atom.Td)
+	// Section headings span the table in a single cell
+	if len(cells) <= 1 {
+		return nil, nil
+	}
+
+	// Number and rarity, normally the first cell
+	numberCell := -1
+	var number data.ExpansionCardNumber
+	var rarityImage *html.Node
+	for i, c := range cells {
+		match := cardNumberPattern.FindStringSubmatch(extractNodeText(c, " "))
+		if match == nil {
+			continue
+		}
+		value, nErr := strconv.ParseUint(match[1], 10, 16)
+		if nErr != nil {
+			return nil, fmt.Errorf("card number '%v': %w", match[1], nErr)
+		}
+		numberCell = i
+		number = data.ExpansionCardNumber(value)
+		for d := range c.Descendants() {
+			if d.DataAtom == atom.Img {
+				rarityImage = d
+			}
+		}
+		break
+	}
+	if numberCell == -1 {
+		p.warn("no card number, not a card")
+		return nil, nil
+	}
+
+	// Name, the first linked text after the number, normally the third cell
+	nameCell := -1
+	var name string
+	for i := numberCell + 1; i < len(cells) && name == ""; i++ {
+		for d := range cells[i].Descendants() {
+			if d.DataAtom == atom.A {
+				if text := extractNodeText(d, " "); text != "" {
+					name = text
+					nameCell = i
+					break
+				}
+			}
+		}
+	}
+	if name == "" {
+		return nil, fmt.Errorf("no name for #%v", number)
+	}
+
+	if rarityImage == nil {
+		return nil, fmt.Errorf("no rarity image for #%v %v", number, name)
+	}
+	rarityImageName, sFound := imageName(rarityImage)
+	if !sFound {
+		return nil, fmt.Errorf("no rarity image src for #%v %v", number, name)
+	}
+	rarity, rFound := data.Rarities.ByImageName(rarityImageName)
+	if !rFound {
+		if rarityImageNamePattern.MatchString(rarityImageName) {
+			return nil, fmt.Errorf("unknown rarity image '%v' for #%v %v, a new rarity?", rarityImageName, number, name)
+		}
+		return nil, fmt.Errorf("unknown rarity image '%v' for #%v %v", rarityImageName, number, name)
+	}
+
+	// Details, normally the cell after the name
+	if nameCell+1 >= len(cells) {
+		p.warn("no details for #%v %v", number, name)
+		return data.NewCard(data.NewBaseCard(name, 0, 0), number, rarity), nil
+	}
+	base, bErr := p.parseDetails(cells[nameCell+1], name)
+	if bErr != nil {
+		return nil, fmt.Errorf("#%v %v: %w", number, name, bErr)
+	}
+	return data.NewCard(base, number, rarity), nil
+}
+
+// Pokémon details are a table of the type, stage, health and retreat cost,
+// trainers only have text.
+func (p *rowParser) parseDetails(cell *html.Node, name string) (*data.BaseCard, error) {
+	var info *html.Node
+	for c := range cell.ChildNodes() {
+		if c.Type == html.TextNode && strings.TrimSpace(c.Data) == "" {
+			continue
+		}
+		info = c
+		break
+	}
+	if info == nil || info.DataAtom != atom.Table {
+		return data.NewTrainerBaseCard(name), nil
+	}
+
+	var healthText string
+	var rows []*html.Node
+	for d := range info.Descendants() {
+		switch d.DataAtom {
+		case atom.B:
+			newHealthText := extractNodeText(d, "")
+			if !strings.Contains(newHealthText, "HP") {
+				continue
+			}
+			if healthText != "" {
+				return nil, fmt.Errorf("multiple health values '%v' '%v'", healthText, newHealthText)
+			}
+			healthText = newHealthText
+		case atom.Tr:
+			rows = append(rows, d)
+		}
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("no rows in the details table")
+	}
+
+	var health uint8
+	if healthText == "" {
+		p.warn("no health for %v", name)
+	} else {
+		rawHealthText := strings.TrimSpace(strings.TrimSuffix(healthText, "HP"))
+		parsedHealth, hErr := strconv.ParseUint(rawHealthText, 10, 8)
+		if hErr != nil {
+			return nil, fmt.Errorf("couldn't parse health '%s': %w", rawHealthText, hErr)
+		}
+		health = uint8(parsedHealth)
+	}
+
+	// Retreat cost, one icon per energy, normally in the third row
+	retreatRowIndex := slices.IndexFunc(rows, func(r *html.Node) bool {
+		return strings.Contains(extractNodeText(r, " "), "Retreat")
+	})
+	if retreatRowIndex == -1 && len(rows) == 3 {
+		retreatRowIndex = 2
+	}
+	var retreatCost uint8
+	if retreatRowIndex == -1 {
+		p.warn("no retreat cost for %v in %v detail rows", name, len(rows))
+	} else {
+		var retreatCells []*html.Node
+		for c := range rows[retreatRowIndex].Descendants() {
+			if c.DataAtom == atom.Td {
+				retreatCells = append(retreatCells, c)
+			}
+		}
+		if len(retreatCells) != 2 {
+			p.warn("%v retreat cells for %v, expected 2", len(retreatCells), name)
+		}
+		if len(retreatCells) > 0 {
+			for c := range retreatCells[len(retreatCells)-1].Descendants() {
+				if c.DataAtom == atom.Img {
+					retreatCost += 1
+				}
+			}
+		}
+	}
+
+	// Type icon and stage, left unknown when not shown
+	energyType, stage := parsePokemonTypeAndStage(rows[0])
+	return data.NewPokemonBaseCard(name, health, retreatCost, stage, energyType), nil
+}
+
+// Parses the cards on a booster page. Rows without a card number, duplicate
+// numbers and missing details are warnings, which fail the page when strict.
+// A card that can't be parsed always fails the page, as leaving it out would
+// skew the expansion's odds and completion. Base cards are shared between
+// printings of the same card.
+func ParseBoosterPage(url string, body []byte, strict bool) (*ParsedPage, error) {
+	doc, dErr := html.Parse(bytes.NewReader(body))
+	if dErr != nil {
+		return nil, dErr
+	}
+	table, tErr := getOnlyDexTable(doc)
+	if tErr != nil {
+		return nil, tErr
+	}
+
+	page := &ParsedPage{}
+	var baseCards []*data.BaseCard
+	numbers := make(map[data.ExpansionCardNumber]bool)
+	var unparsed []string
+	for i, r := range getImmediateRows(table) {
+		parser := &rowParser{row: r}
+		card, pErr := parser.parse()
+		if pErr != nil {
+			unparsed = append(unparsed, (&ParseWarning{url: url, row: i, snippet: snippet(r), message: pErr.Error()}).String())
+			continue
+		}
+		if card != nil && numbers[card.Number()] {
+			parser.warnings = append(parser.warnings, fmt.Sprintf("duplicate #%v %v", card.Number(), card.Name()))
+			card = nil
+		}
+		for _, w := range parser.warnings {
+			warning := &ParseWarning{url: url, row: i, snippet: snippet(r), message: w}
+			if strict {
+				return nil, fmt.Errorf("strict parsing: %v", warning)
+			}
+			page.warnings = append(page.warnings, warning)
+		}
+		if card == nil {
+			continue
+		}
+		numbers[card.Number()] = true
+
+		baseIndex := slices.IndexFunc(baseCards, card.Base().IsEqual)
+		if baseIndex == -1 {
+			baseCards = append(baseCards, card.Base())
+		} else {
+			card = data.NewCard(baseCards[baseIndex], card.Number(), card.Rarity())
+		}
+		page.cards = append(page.cards, card)
+	}
+	if len(unparsed) > 0 {
+		return nil, fmt.Errorf("%v cards couldn't be parsed:\n  %v", len(unparsed), strings.Join(unparsed, "\n  "))
+	}
+	if len(page.cards) == 0 {
+		return nil, errors.New("no cards found")
+	}
+	return page, nil
+}
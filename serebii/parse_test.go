@@ -0,0 +1,91 @@
+package serebii
+
+import (
+	"ptcgpocket/data"
+	"strings"
+	"testing"
+)
+
+const testPageUrl = "https://www.serebii.net/tcgpocket/test/booster.shtml"
+
+func testPage(rows ...string) []byte {
+	return []byte(`<html><body><table class="dextable"><tr><td>Header</td></tr>` +
+		strings.Join(rows, "\n") +
+		`</table></body></html>`)
+}
+
+const pikachuRow = `<tr>
+<td><img src="/tcgpocket/image/diamond4.png"> 96 / 286</td>
+<td><img src="/card.png"></td>
+<td><a href="/pikachu">Pikachu ex</a></td>
+<td>
+	<table>
+		<tr><td><img src="/tcgpocket/image/lightning.png"> Basic</td><td><b>120 HP</b></td></tr>
+		<tr><td>Circle Circuit</td></tr>
+		<tr><td>Retreat</td><td><img src="/colorless.png"></td></tr>
+	</table>
+</td>
+<td></td><td></td><td></td>
+</tr>`
+
+const potionRow = `<tr>
+<td><img src="/tcgpocket/image/diamond1.png"> 1 / 286</td>
+<td><img src="/card.png"></td>
+<td><a href="/potion">Potion</a></td>
+<td>Heal 20 damage.</td>
+<td></td><td></td><td></td>
+</tr>`
+
+// A page without the card image column
+const potionShortRow = `<tr>
+<td><img src="/tcgpocket/image/diamond1.png"> 2 / 286</td>
+<td><a href="/potion">Potion</a></td>
+<td>Heal 20 damage.</td>
+</tr>`
+
+const newRarityRow = `<tr>
+<td><img src="/tcgpocket/image/diamond5.png"> 3 / 286</td>
+<td></td>
+<td><a href="/mew">Mew</a></td>
+<td>Heal 20 damage.</td>
+</tr>`
+
+func TestParseBoosterPage(t *testing.T) {
+	body := testPage(pikachuRow, `<tr><td colspan="7">Secret cards</td></tr>`, potionRow, potionShortRow, potionRow)
+	page, err := ParseBoosterPage(testPageUrl, body, false)
+	if err != nil {
+		t.Fatalf("ParseBoosterPage error = %v; want nil", err)
+	}
+	if len(page.Cards()) != 3 {
+		t.Fatalf("ParseBoosterPage cards = %v; want 3", len(page.Cards()))
+	}
+
+	pikachu := page.Cards()[0]
+	if pikachu.Number() != 96 || pikachu.Rarity() != data.RarityFourDiamond || pikachu.Base().Health() != 120 ||
+		pikachu.Base().RetreatCost() != 1 || pikachu.Base().EnergyType() != data.EnergyTypeLightning ||
+		pikachu.Base().Stage() != data.StageBasic {
+		t.Errorf("Pikachu = #%v %v %+v; want #96 ♢♢♢♢ 120HP 1 retreat lightning basic", pikachu.Number(), pikachu.Rarity(), pikachu.Base())
+	}
+	if page.Cards()[1].Base() != page.Cards()[2].Base() {
+		t.Errorf("Potion printings don't share a base card")
+	}
+
+	if len(page.Warnings()) != 1 {
+		t.Fatalf("ParseBoosterPage warnings = %v; want 1 for the duplicate", page.Warnings())
+	}
+	if w := page.Warnings()[0]; w.Row() != 4 || !strings.Contains(w.Message(), "duplicate #1") || !strings.Contains(w.Snippet(), "Potion") {
+		t.Errorf("Warning = %v; want row 4 with the duplicate and the row HTML", w)
+	}
+
+	if _, err := ParseBoosterPage(testPageUrl, body, true); err == nil {
+		t.Errorf("Strict ParseBoosterPage error = nil; want error")
+	}
+}
+
+func TestParseBoosterPageUnparsedCard(t *testing.T) {
+	body := testPage(pikachuRow, potionRow, newRarityRow)
+	_, err := ParseBoosterPage(testPageUrl, body, false)
+	if err == nil || !strings.Contains(err.Error(), "diamond5") || !strings.Contains(err.Error(), "Mew") {
+		t.Errorf("ParseBoosterPage error = %v; want the unknown rarity with the row HTML", err)
+	}
+}
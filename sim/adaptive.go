@@ -0,0 +1,178 @@
+package sim
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"iter"
+	"maps"
+	"ptcgpocket/data"
+	"ptcgpocket/userdata"
+	"slices"
+	"time"
+)
+
+// When to stop running batches of simulations.
+type AdaptiveOptions struct {
+	// Target half width of the 95% confidence interval, in packs opened.
+	tolerance  float64
+	batchSize  uint64
+	timeBudget time.Duration
+}
+
+func NewAdaptiveOptions(tolerance float64, batchSize uint64, timeBudget time.Duration) *AdaptiveOptions {
+	return &AdaptiveOptions{
+		tolerance:  tolerance,
+		batchSize:  max(batchSize, 2),
+		timeBudget: timeBudget,
+	}
+}
+
+func (o *AdaptiveOptions) Tolerance() float64 {
+	return o.tolerance
+}
+
+func (o *AdaptiveOptions) BatchSize() uint64 {
+	return o.batchSize
+}
+
+func (o *AdaptiveOptions) TimeBudget() time.Duration {
+	return o.timeBudget
+}
+
+// The precision achieved for mean packs opened.
+type SimPrecision struct {
+	runs           uint64
+	converged      bool
+	elapsed        time.Duration
+	expansionStats map[*data.Expansion]*RunningStat
+	totalStat      *RunningStat
+}
+
+func newSimPrecision(expansions []*data.Expansion) *SimPrecision {
+	expansionStats := make(map[*data.Expansion]*RunningStat, len(expansions))
+	for _, e := range expansions {
+		expansionStats[e] = &RunningStat{}
+	}
+	return &SimPrecision{expansionStats: expansionStats, totalStat: &RunningStat{}}
+}
+
+func (p *SimPrecision) add(r *SimRun) {
+	p.runs++
+	for e, s := range p.expansionStats {
+		// Expansions complete from the start have no run, they took 0 packs
+		var opened uint64
+		if eRun, eFound := r.expansionRuns[e]; eFound {
+			opened = eRun.numOpened
+		}
+		s.Add(float64(opened))
+	}
+	p.totalStat.Add(float64(r.TotalPacksOpened()))
+}
+
+func (p *SimPrecision) widestHalfWidth() float64 {
+	widest := p.totalStat.ConfidenceHalfWidth95()
+	for _, s := range p.expansionStats {
+		widest = max(widest, s.ConfidenceHalfWidth95())
+	}
+	return widest
+}
+
+func (p *SimPrecision) Runs() uint64 {
+	return p.runs
+}
+
+// Whether the tolerance was met, as opposed to running out of time.
+func (p *SimPrecision) Converged() bool {
+	return p.converged
+}
+
+func (p *SimPrecision) Elapsed() time.Duration {
+	return p.elapsed
+}
+
+func (p *SimPrecision) ExpansionStats() iter.Seq2[*data.Expansion, *RunningStat] {
+	return maps.All(p.expansionStats)
+}
+
+func (p *SimPrecision) ExpansionStat(e *data.Expansion) *RunningStat {
+	return p.expansionStats[e]
+}
+
+func (p *SimPrecision) TotalStat() *RunningStat {
+	return p.totalStat
+}
+
+// Keeps running batches of simulations until the 95% confidence interval of
+// mean packs opened, for every expansion and in total, is within the
+// tolerance, or the time budget elapses. Runs are numbered and seeded the
+// same as RunAllSimulations and the stopping decision is only made between
+// batches, so without a time budget the number of runs is reproducible for a
+// seed.
+func RunAdaptiveSimulations(
+	expansions []*data.Expansion,
+	userCollection *userdata.UserCollection,
+	completePredicate ExpansionSimCompletePredicate,
+	options *SimOptions,
+	randomSeed uint64,
+	workers int,
+	adaptiveOptions *AdaptiveOptions,
+	ctx context.Context,
+	results chan<- *SimRun,
+) (*SimPrecision, error) {
+	start := time.Now()
+	batchCtx := ctx
+	if adaptiveOptions.timeBudget > 0 {
+		var cancel context.CancelFunc
+		batchCtx, cancel = context.WithTimeout(ctx, adaptiveOptions.timeBudget)
+		defer cancel()
+	}
+
+	precision := newSimPrecision(expansions)
+	var from uint64
+	for {
+		batchResults := make(chan *SimRun, adaptiveOptions.batchSize)
+		bErr := runSimulationRange(
+			expansions,
+			userCollection,
+			completePredicate,
+			options,
+			from,
+			from+adaptiveOptions.batchSize,
+			randomSeed,
+			workers,
+			batchCtx,
+			batchResults,
+		)
+		close(batchResults)
+
+		// Add in index order so the statistics don't depend on scheduling
+		var batch []*SimRun
+		for r := range batchResults {
+			batch = append(batch, r)
+		}
+		slices.SortFunc(batch, func(r1, r2 *SimRun) int {
+			return cmp.Compare(r1.index, r2.index)
+		})
+		for _, r := range batch {
+			precision.add(r)
+			results <- r
+		}
+		precision.elapsed = time.Since(start)
+
+		if ctx.Err() != nil {
+			return precision, ctx.Err()
+		}
+		if bErr != nil && !errors.Is(bErr, context.DeadlineExceeded) {
+			return precision, bErr
+		}
+		if precision.widestHalfWidth() <= adaptiveOptions.tolerance {
+			precision.converged = true
+			return precision, nil
+		}
+		if batchCtx.Err() != nil {
+			return precision, nil
+		}
+		from += adaptiveOptions.batchSize
+	}
+}
@@ -0,0 +1,168 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"math"
+	"ptcgpocket/data"
+	"ptcgpocket/userdata"
+)
+
+// Chance of obtaining every target card within a number of packs, and the
+// target cards expected to still be missing afterwards.
+type BudgetEstimate struct {
+	packs                 uint64
+	runs                  uint64
+	completionProbability float64
+	expectedMissing       map[*data.Rarity]float64
+}
+
+func (e *BudgetEstimate) Packs() uint64 {
+	return e.packs
+}
+
+// Number of simulations behind the estimate, 0 when calculated analytically.
+func (e *BudgetEstimate) Runs() uint64 {
+	return e.runs
+}
+
+func (e *BudgetEstimate) CompletionProbability() float64 {
+	return e.completionProbability
+}
+
+func (e *BudgetEstimate) ExpectedMissing(rarity *data.Rarity) float64 {
+	return e.expectedMissing[rarity]
+}
+
+func (e *BudgetEstimate) ExpectedMissingTotal() float64 {
+	total := 0.0
+	for _, m := range e.expectedMissing {
+		total += m
+	}
+	return total
+}
+
+// Rarities with any target cards, in rarity order.
+func (e *BudgetEstimate) ExpectedMissingByRarity() iter.Seq2[*data.Rarity, float64] {
+	return func(yield func(*data.Rarity, float64) bool) {
+		for r := range data.Rarities.All() {
+			m, mFound := e.expectedMissing[r]
+			if !mFound {
+				continue
+			}
+			if !yield(r, m) {
+				return
+			}
+		}
+	}
+}
+
+func newBudgetEstimate(expansion *data.Expansion, targets *data.CardSet, packs uint64) *BudgetEstimate {
+	estimate := &BudgetEstimate{packs: packs, expectedMissing: make(map[*data.Rarity]float64)}
+	for c := range expansion.CardsIn(targets) {
+		estimate.expectedMissing[c.Rarity()] = 0
+	}
+	return estimate
+}
+
+// Estimates the chance of obtaining every missing target card by opening the
+// booster that best offers them the given number of times. Packs are
+// independent so each card's chance of still being missing is exact, but the
+// completion probability treats cards as independent of each other, and pack
+// points aren't used.
+func EstimateBudgetAnalytically(
+	expansion *data.Expansion,
+	missing *data.CardSet,
+	targets *data.CardSet,
+	packs uint64,
+) (*BudgetEstimate, *data.Booster, error) {
+	missingTargets := missing.Intersection(targets)
+	estimate := newBudgetEstimate(expansion, missingTargets, packs)
+	if missingTargets.IsEmpty() {
+		estimate.completionProbability = 1
+		return estimate, nil, nil
+	}
+
+	booster, bErr := expansion.GetHighestOfferingBoosterForMissingCards(missingTargets)
+	if bErr != nil {
+		return nil, nil, bErr
+	}
+
+	estimate.completionProbability = 1
+	for c := range expansion.CardsIn(missingTargets) {
+		stillMissing := math.Pow(booster.ProbabilityNotInInstance(c), float64(packs))
+		estimate.expectedMissing[c.Rarity()] += stillMissing
+		estimate.completionProbability *= 1 - stillMissing
+	}
+	return estimate, booster, nil
+}
+
+// Estimates the chance of obtaining every missing target card within the
+// given number of packs by simulation, following the same strategy as
+// RunSim including pack point redemptions. Boosters and redemptions are
+// chosen for the target cards, as EstimateBudgetAnalytically chooses its
+// booster.
+func SimulateBudget(
+	expansion *data.Expansion,
+	userCollection *userdata.UserCollection,
+	targets *data.CardSet,
+	packs uint64,
+	runs uint64,
+	randomSeed uint64,
+	workers int,
+	ctx context.Context,
+) (*BudgetEstimate, error) {
+	missing, mFound := userCollection.MissingSetForExpansion(expansion.Id())
+	if !mFound {
+		return nil, fmt.Errorf("no collection for expansion %v", expansion.Id())
+	}
+	estimate := newBudgetEstimate(expansion, missing.Intersection(targets), packs)
+	weights := make(data.CardWeights)
+	for c := range expansion.CardsIn(targets) {
+		weights[c.Number()] = 1
+	}
+
+	results := make(chan *SimRun, max(workers, 1))
+	var simErr error
+	go func() {
+		simErr = RunAllSimulations(
+			[]*data.Expansion{expansion},
+			userCollection,
+			func(e *data.Expansion, c *userdata.ExpansionCollection) bool {
+				return !c.Missing().Intersects(targets)
+			},
+			NewSimOptions(false, packs, map[data.ExpansionId]data.CardWeights{expansion.Id(): weights}),
+			runs,
+			randomSeed,
+			workers,
+			ctx,
+			results,
+		)
+		close(results)
+	}()
+
+	var completed uint64
+	for r := range results {
+		estimate.runs++
+		eRun, eFound := r.expansionRuns[expansion]
+		if !eFound || eRun.completed {
+			completed++
+			continue
+		}
+		for c := range expansion.CardsIn(eRun.missing.Intersection(targets)) {
+			estimate.expectedMissing[c.Rarity()]++
+		}
+	}
+	if simErr != nil {
+		return nil, simErr
+	}
+
+	if estimate.runs > 0 {
+		estimate.completionProbability = float64(completed) / float64(estimate.runs)
+		for r, m := range estimate.expectedMissing {
+			estimate.expectedMissing[r] = m / float64(estimate.runs)
+		}
+	}
+	return estimate, nil
+}
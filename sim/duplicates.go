@@ -0,0 +1,124 @@
+package sim
+
+import (
+	"iter"
+	"math"
+	"ptcgpocket/data"
+)
+
+// Duplicates expected to be opened by the time a collection is completed.
+// Simulated estimates accumulate runs with Add.
+type DuplicateEstimate struct {
+	packs      float64
+	runs       uint64
+	duplicates map[*data.Rarity]float64
+}
+
+// An empty estimate to Add simulation runs to.
+func NewSimulatedDuplicates() *DuplicateEstimate {
+	return &DuplicateEstimate{duplicates: make(map[*data.Rarity]float64)}
+}
+
+func (e *DuplicateEstimate) Add(run *ExpansionSimRun) {
+	e.runs++
+	e.packs += float64(run.numOpened)
+	for r, d := range run.DuplicatesByRarity() {
+		if d > 0 {
+			e.duplicates[r] += float64(d)
+		}
+	}
+}
+
+func (e *DuplicateEstimate) divisor() float64 {
+	if e.runs == 0 {
+		return 1
+	}
+	return float64(e.runs)
+}
+
+// Mean packs opened.
+func (e *DuplicateEstimate) Packs() float64 {
+	return e.packs / e.divisor()
+}
+
+// Number of simulations behind the estimate, 0 when calculated analytically.
+func (e *DuplicateEstimate) Runs() uint64 {
+	return e.runs
+}
+
+func (e *DuplicateEstimate) Expected(rarity *data.Rarity) float64 {
+	return e.duplicates[rarity] / e.divisor()
+}
+
+func (e *DuplicateEstimate) ExpectedTotal() float64 {
+	total := 0.0
+	for _, d := range e.duplicates {
+		total += d
+	}
+	return total / e.divisor()
+}
+
+// Rarities with any duplicates, in rarity order.
+func (e *DuplicateEstimate) ExpectedByRarity() iter.Seq2[*data.Rarity, float64] {
+	return func(yield func(*data.Rarity, float64) bool) {
+		for r := range data.Rarities.All() {
+			if _, dFound := e.duplicates[r]; !dFound {
+				continue
+			}
+			if !yield(r, e.Expected(r)) {
+				return
+			}
+		}
+	}
+}
+
+// Expected amount of each resource, when every duplicate is converted to it.
+func (e *DuplicateEstimate) Resources(table *data.ResourceTable) map[string]float64 {
+	expected := make(map[*data.Rarity]float64, len(e.duplicates))
+	for r, d := range e.ExpectedByRarity() {
+		expected[r] = d
+	}
+	return table.Convert(expected)
+}
+
+// Estimates the duplicates opened in the given number of packs of the booster
+// that best offers the missing cards. Every copy of a card is a duplicate
+// except the first of each missing card, so this is exact for a single
+// booster but doesn't follow RunSim's switching of boosters or pack point
+// redemptions.
+func EstimateDuplicatesAnalytically(
+	expansion *data.Expansion,
+	missing *data.CardSet,
+	packs float64,
+) (*DuplicateEstimate, *data.Booster, error) {
+	estimate := &DuplicateEstimate{packs: packs, duplicates: make(map[*data.Rarity]float64)}
+
+	var booster *data.Booster
+	if missing.IsEmpty() {
+		for b := range expansion.Boosters() {
+			booster = b
+			break
+		}
+	} else {
+		var bErr error
+		booster, bErr = expansion.GetHighestOfferingBoosterForMissingCards(missing)
+		if bErr != nil {
+			return nil, nil, bErr
+		}
+	}
+	if booster == nil {
+		return estimate, nil, nil
+	}
+
+	for o := range booster.Offerings() {
+		c := o.Card()
+		duplicates := packs * booster.ExpectedCopiesInInstance(c)
+		if missing.Contains(c) {
+			duplicates -= 1 - math.Pow(booster.ProbabilityNotInInstance(c), packs)
+		}
+		if duplicates > 0 {
+			estimate.duplicates[c.Rarity()] += duplicates
+		}
+	}
+	return estimate, booster, nil
+}
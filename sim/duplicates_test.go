@@ -0,0 +1,71 @@
+package sim
+
+import (
+	"context"
+	"math"
+	"ptcgpocket/data"
+	"ptcgpocket/internal/testexpansion"
+	"testing"
+)
+
+func TestEstimateDuplicatesAnalyticallyWhenOwned(t *testing.T) {
+	e := testexpansion.GeneticApexShaped("test")
+	estimate, _, err := EstimateDuplicatesAnalytically(e, data.NewCardSet(), 10)
+	if err != nil {
+		t.Fatalf("EstimateDuplicatesAnalytically returned error %v", err)
+	}
+	// Every card of 5 card packs is a duplicate
+	if total := estimate.ExpectedTotal(); math.Abs(total-50) > 1e-6 {
+		t.Errorf("ExpectedTotal = %v; want 50", total)
+	}
+}
+
+func TestDuplicateEstimatesAgree(t *testing.T) {
+	expansions := []*data.Expansion{testexpansion.GeneticApexShaped("test")}
+	collection := newEmptyTestCollection(expansions)
+	missing, _ := collection.MissingSetForExpansion("test")
+
+	results := make(chan *SimRun, 1)
+	var simErr error
+	go func() {
+		simErr = RunAllSimulations(
+			expansions,
+			collection,
+			isWholeExpansionComplete,
+			NewSimOptions(false, 100, nil),
+			200,
+			3,
+			0,
+			context.Background(),
+			results,
+		)
+		close(results)
+	}()
+	simulated := NewSimulatedDuplicates()
+	for r := range results {
+		for _, eRun := range r.ExpansionRuns() {
+			simulated.Add(eRun)
+		}
+	}
+	if simErr != nil {
+		t.Fatalf("RunAllSimulations returned error %v", simErr)
+	}
+
+	if simulated.Runs() != 200 || simulated.Packs() != 100 {
+		t.Errorf("Simulated runs, packs = %v, %v; want 200, 100", simulated.Runs(), simulated.Packs())
+	}
+	analytic, _, aErr := EstimateDuplicatesAnalytically(expansions[0], missing, simulated.Packs())
+	if aErr != nil {
+		t.Fatalf("EstimateDuplicatesAnalytically returned error %v", aErr)
+	}
+	for _, r := range []*data.Rarity{data.RarityOneDiamond, data.RarityTwoDiamond} {
+		if a, s := analytic.Expected(r), simulated.Expected(r); math.Abs(a-s) > 0.05*a {
+			t.Errorf("Expected %v duplicates analytic %v vs simulated %v; want within 5%%", r, a, s)
+		}
+	}
+
+	tokens := simulated.Resources(data.DefaultResourceTable())["trade tokens"]
+	if tokens <= 0 {
+		t.Errorf("Simulated trade tokens = %v; want > 0", tokens)
+	}
+}
@@ -0,0 +1,140 @@
+package sim
+
+import (
+	"cmp"
+	"math"
+	"ptcgpocket/data"
+	"ptcgpocket/userdata"
+	"slices"
+)
+
+type RedemptionAdvice uint8
+
+const (
+	// Affordable and unlikely to be pulled while earning the points back
+	RedeemNow RedemptionAdvice = iota
+	// Likely to be pulled anyway before the points are earned back
+	WaitToPull
+	// Not enough pack points yet
+	SaveUp
+)
+
+func (a RedemptionAdvice) String() string {
+	switch a {
+	case RedeemNow:
+		return "redeem now"
+	case WaitToPull:
+		return "wait, likely to pull"
+	case SaveUp:
+		return "save up"
+	}
+	return "unknown"
+}
+
+// Above this chance of pulling a card while earning its cost back, redeeming
+// it is more likely than not to waste the points.
+const waitToPullChance = 0.5
+
+// The value of redeeming a missing card with pack points.
+type RedemptionOption struct {
+	card *data.Card
+	// The booster most likely to contain the card, nil when none do
+	booster *data.Booster
+	// Chance a pack of the booster contains the card
+	pullChance float64
+	weight     float64
+	// Chance of pulling the card in the packs it takes to earn its cost
+	pullChanceWhileEarning float64
+	// Packs to open before the card is affordable
+	packsToAfford uint
+	advice        RedemptionAdvice
+}
+
+func (o *RedemptionOption) Card() *data.Card {
+	return o.card
+}
+
+func (o *RedemptionOption) Booster() (*data.Booster, bool) {
+	return o.booster, o.booster != nil
+}
+
+func (o *RedemptionOption) PullChance() float64 {
+	return o.pullChance
+}
+
+func (o *RedemptionOption) Weight() float64 {
+	return o.weight
+}
+
+func (o *RedemptionOption) PullChanceWhileEarning() float64 {
+	return o.pullChanceWhileEarning
+}
+
+func (o *RedemptionOption) PacksToAfford() uint {
+	return o.packsToAfford
+}
+
+func (o *RedemptionOption) Advice() RedemptionAdvice {
+	return o.advice
+}
+
+// Expected packs to open to pull the card, infinite when no booster has it.
+func (o *RedemptionOption) ExpectedPacksToPull() float64 {
+	if o.pullChance == 0 {
+		return math.Inf(1)
+	}
+	return 1 / o.pullChance
+}
+
+// Expected packs not opened for each point spent redeeming the card.
+func (o *RedemptionOption) PacksSavedPerPoint() float64 {
+	return o.ExpectedPacksToPull() / float64(o.card.Rarity().PackPointsToObtain())
+}
+
+func (o *RedemptionOption) value() float64 {
+	return o.weight * o.PacksSavedPerPoint()
+}
+
+// Ranks redeeming each missing card with a weight, by advice and then most
+// packs saved per weighted point. Redeeming now is only advised when the card
+// is affordable and unlikely to be pulled while earning its cost back.
+func RankRedemptions(
+	expansion *data.Expansion,
+	eCollection *userdata.ExpansionCollection,
+	weights data.CardWeights,
+) []*RedemptionOption {
+	pool := eCollection.PackPointPool()
+	var options []*RedemptionOption
+	for c := range expansion.CardsIn(eCollection.Missing()) {
+		weight := weights[c.Number()]
+		if weight <= 0 {
+			continue
+		}
+		option := &RedemptionOption{card: c, weight: weight}
+		for b := range expansion.Boosters() {
+			if chance := 1 - b.ProbabilityNotInInstance(c); chance > option.pullChance {
+				option.pullChance = chance
+				option.booster = b
+			}
+		}
+
+		cost := uint(c.Rarity().PackPointsToObtain())
+		packsToEarnCost, _ := userdata.NewPackPointPool(0).PacksToEarn(cost)
+		option.pullChanceWhileEarning = 1 - math.Pow(1-option.pullChance, float64(packsToEarnCost))
+		option.packsToAfford, _ = pool.PacksToEarn(cost)
+		switch {
+		case !pool.CanRedeem(c.Rarity()):
+			option.advice = SaveUp
+		case option.pullChanceWhileEarning > waitToPullChance:
+			option.advice = WaitToPull
+		default:
+			option.advice = RedeemNow
+		}
+		options = append(options, option)
+	}
+
+	slices.SortStableFunc(options, func(o1, o2 *RedemptionOption) int {
+		return cmp.Or(cmp.Compare(o1.advice, o2.advice), cmp.Compare(o2.value(), o1.value()))
+	})
+	return options
+}
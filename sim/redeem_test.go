@@ -0,0 +1,43 @@
+package sim
+
+import (
+	"ptcgpocket/data"
+	"ptcgpocket/internal/testexpansion"
+	"ptcgpocket/userdata"
+	"testing"
+)
+
+func TestRankRedemptions(t *testing.T) {
+	e := testexpansion.GeneticApexShaped("genetic-apex")
+	oneDiamond, _ := e.GetCardByNumber(1)
+	fourDiamond, _ := e.GetCardByNumber(206)
+	crown, _ := e.GetCardByNumber(278)
+	eCollection := userdata.NewExpansionCollection(e, data.NewCardSet(oneDiamond, fourDiamond, crown), 600)
+
+	weights := data.CardWeights{
+		oneDiamond.Number():  userdata.CollectionCardWeight,
+		fourDiamond.Number(): userdata.PriorityMustHave.Weight(),
+		crown.Number():       userdata.CollectionCardWeight,
+	}
+	options := RankRedemptions(e, eCollection, weights)
+	if len(options) != 3 {
+		t.Fatalf("RankRedemptions = %v options; want 3", len(options))
+	}
+
+	if o := options[0]; o.Card() != fourDiamond || o.Advice() != RedeemNow {
+		t.Errorf("First redemption = #%v %v; want #%v redeem now", o.Card().Number(), o.Advice(), fourDiamond.Number())
+	}
+	// Cheap but saves fewer packs per point than the must have
+	if o := options[1]; o.Card() != oneDiamond || o.Advice() != RedeemNow {
+		t.Errorf("Second redemption = #%v %v; want #%v redeem now", o.Card().Number(), o.Advice(), oneDiamond.Number())
+	}
+	if o := options[2]; o.Card() != crown || o.Advice() != SaveUp || o.PacksToAfford() != 380 {
+		t.Errorf(
+			"Third redemption = #%v %v in %v packs; want #%v save up in 380 packs",
+			o.Card().Number(),
+			o.Advice(),
+			o.PacksToAfford(),
+			crown.Number(),
+		)
+	}
+}
@@ -8,15 +8,83 @@ import (
 	"math/rand/v2"
 	"ptcgpocket/data"
 	"ptcgpocket/userdata"
+	"runtime"
+	"slices"
 
 	"golang.org/x/sync/errgroup"
 )
 
+// Per run behaviour of the simulation.
+type SimOptions struct {
+	recordAcquisitions bool
+	// Most packs opened per expansion, 0 for no limit.
+	packBudget uint64
+	// Expansions without weights choose boosters by chance of any missing
+	// card.
+	boosterWeights map[data.ExpansionId]data.CardWeights
+	// When set, only the wishlist's cards still wanted are targeted, including
+	// further copies of owned cards.
+	wishlist *userdata.Wishlist
+}
+
+func NewSimOptions(
+	recordAcquisitions bool,
+	packBudget uint64,
+	boosterWeights map[data.ExpansionId]data.CardWeights,
+) *SimOptions {
+	return &SimOptions{
+		recordAcquisitions: recordAcquisitions,
+		packBudget:         packBudget,
+		boosterWeights:     boosterWeights,
+	}
+}
+
+var DefaultSimOptions = NewSimOptions(false, 0, nil)
+
+// Packs opened in each expansion before giving up on completing it, 0 when
+// expansions are always completed.
+func (o *SimOptions) PackBudget() uint64 {
+	return o.packBudget
+}
+
+// Whether each run records when and how every missing card was obtained,
+// see ExpansionSimRun.Acquisitions.
+func (o *SimOptions) RecordAcquisitions() bool {
+	return o.recordAcquisitions
+}
+
+// Weights steering booster choice and pack point redemption towards valued
+// cards in the expansion.
+func (o *SimOptions) BoosterWeights(expansionId data.ExpansionId) (data.CardWeights, bool) {
+	w, wFound := o.boosterWeights[expansionId]
+	return w, wFound
+}
+
+// Returns a copy of the options with booster weights set.
+func (o *SimOptions) WithBoosterWeights(boosterWeights map[data.ExpansionId]data.CardWeights) *SimOptions {
+	withWeights := *o
+	withWeights.boosterWeights = boosterWeights
+	return &withWeights
+}
+
+// Returns a copy of the options targeting the wishlist's cards, see
+// NewWishlistCompletePredicate.
+func (o *SimOptions) WithWishlist(wishlist *userdata.Wishlist) *SimOptions {
+	withWishlist := *o
+	withWishlist.wishlist = wishlist
+	return &withWishlist
+}
+
 type ExpansionSimRun struct {
 	numOpened                      uint64
 	totalPackPoints                uint64
 	numCardsObtainedFromPackPoints uint64
 	numRarePacks                   uint64
+	acquisitions                   []*CardAcquisition
+	completed                      bool
+	missing                        *data.CardSet
+	// Cards opened that were already owned, indexed by rarity order
+	duplicates []uint64
 }
 
 func NewExpansionSimRun(
@@ -49,10 +117,60 @@ func (r *ExpansionSimRun) NumRarePacks() uint64 {
 	return r.numRarePacks
 }
 
+// Cards opened that were already owned, including extra copies in the pack
+// that obtained the card.
+func (r *ExpansionSimRun) Duplicates(rarity *data.Rarity) uint64 {
+	if int(rarity.Order()) >= len(r.duplicates) {
+		return 0
+	}
+	return r.duplicates[rarity.Order()]
+}
+
+// Duplicates of each rarity opened, in rarity order.
+func (r *ExpansionSimRun) DuplicatesByRarity() iter.Seq2[*data.Rarity, uint64] {
+	return func(yield func(*data.Rarity, uint64) bool) {
+		for rarity := range data.Rarities.All() {
+			if !yield(rarity, r.Duplicates(rarity)) {
+				return
+			}
+		}
+	}
+}
+
+func (r *ExpansionSimRun) recordDuplicate(rarity *data.Rarity) {
+	if int(rarity.Order()) >= len(r.duplicates) {
+		r.duplicates = append(r.duplicates, make([]uint64, int(rarity.Order())+1-len(r.duplicates))...)
+	}
+	r.duplicates[rarity.Order()]++
+}
+
+// Whether the complete predicate was met, rather than running out of
+// SimOptions.PackBudget.
+func (r *ExpansionSimRun) Completed() bool {
+	return r.completed
+}
+
+// Cards still missing when the expansion's simulation stopped.
+func (r *ExpansionSimRun) Missing() *data.CardSet {
+	return r.missing
+}
+
+// Missing cards in the order they were obtained, only recorded when
+// SimOptions.RecordAcquisitions is set.
+func (r *ExpansionSimRun) Acquisitions() iter.Seq[*CardAcquisition] {
+	return slices.Values(r.acquisitions)
+}
+
 type SimRun struct {
+	index         uint64
 	expansionRuns map[*data.Expansion]*ExpansionSimRun
 }
 
+// Position of the run within RunAllSimulations, which determines its seed.
+func (r *SimRun) Index() uint64 {
+	return r.index
+}
+
 func (r *SimRun) TotalPacksOpened() uint64 {
 	var total uint64
 	for _, n := range r.expansionRuns {
@@ -65,139 +183,317 @@ func (r *SimRun) ExpansionRuns() iter.Seq2[*data.Expansion, *ExpansionSimRun] {
 	return maps.All(r.expansionRuns)
 }
 
-type ExpansionSimCompletePredicate func(*data.Expansion, []*data.Card) bool
-
-var packPointsPerOpening uint64 = 5
+// Decides whether an expansion is complete given its collection. The
+// collection is live and must not be modified or retained.
+type ExpansionSimCompletePredicate func(*data.Expansion, *userdata.ExpansionCollection) bool
 
 func RunSim(
 	expansions []*data.Expansion,
 	userCollection *userdata.UserCollection,
 	expansionCompletePredicate ExpansionSimCompletePredicate,
+	options *SimOptions,
 	randomGenerator *rand.Rand,
 ) (*SimRun, error) {
 	simCollection := userCollection.Clone()
 	expansionRuns := make(map[*data.Expansion]*ExpansionSimRun)
 	for _, e := range expansions {
-		isExpansionComplete := false
-		for !isExpansionComplete {
-			eCollection := simCollection.GetExpansionCollection(e.Id())
-			missing, missingFound := simCollection.MissingForExpansion(e.Id())
-			if !missingFound {
-				panic("No missing found")
-			}
+		eCollection := simCollection.GetExpansionCollection(e.Id())
+		if eCollection == nil {
+			panic("No missing found")
+		}
+		missing := eCollection.Missing()
+		if expansionCompletePredicate(e, eCollection) {
+			continue
+		}
 
-			if expansionCompletePredicate(e, missing) {
-				isExpansionComplete = true
-				continue
+		simulator := newExpansionSimulator(e, eCollection, options)
+		expansionRuns[e] = simulator.run
+		for {
+			if expansionCompletePredicate(e, eCollection) {
+				simulator.run.completed = true
+				simulator.run.missing = missing.Clone()
+				break
 			}
-
-			eSimRun := expansionRuns[e]
-			if eSimRun == nil {
-				eSimRun = &ExpansionSimRun{}
-				expansionRuns[e] = eSimRun
+			// Out of packs, leave the expansion incomplete
+			if _, outOfBudget := simulator.step(randomGenerator); outOfBudget {
+				simulator.run.missing = missing.Clone()
+				break
 			}
+		}
+	}
 
-			// Decide, should we trade in pack points or pick a booster?
-			// TODO: Can exit early, but needs some careful thought on exact conditions
-			var highestPackPointsCard *data.Card
-			var packPointsToObtainAllMissing uint64 = 0
-			for _, card := range missing {
-				packPointsToObtainAllMissing += uint64(card.Rarity().PackPointsToObtain())
-				if highestPackPointsCard == nil || card.Rarity().PackPointsToObtain() > highestPackPointsCard.Rarity().PackPointsToObtain() {
-					highestPackPointsCard = card
-				}
-			}
+	return &SimRun{expansionRuns: expansionRuns}, nil
+}
 
-			// We have max pack points, use some now so can continue to accrue
-			if eCollection.PackPoints() == data.MaxPackPointsPerBooster {
-				if highestPackPointsCard == nil {
-					panic("No highest pack point card")
-				}
+// Completes one expansion collection following RunSim's strategy.
+type expansionSimulator struct {
+	expansion   *data.Expansion
+	eCollection *userdata.ExpansionCollection
+	options     *SimOptions
+	run         *ExpansionSimRun
+	// Cards new to the collection in the last pack opened, reused between
+	// packs to avoid allocating for each
+	newCards []*data.Card
+}
 
-				eCollection.AcquireCardUsingPackPoints(highestPackPointsCard)
-				eSimRun.numCardsObtainedFromPackPoints += 1
-				continue
-			}
+func newExpansionSimulator(
+	expansion *data.Expansion,
+	eCollection *userdata.ExpansionCollection,
+	options *SimOptions,
+) *expansionSimulator {
+	return &expansionSimulator{
+		expansion:   expansion,
+		eCollection: eCollection,
+		options:     options,
+		run:         &ExpansionSimRun{},
+	}
+}
 
-			// We have enough pack points to complete our collection
-			if packPointsToObtainAllMissing > 0 && packPointsToObtainAllMissing <= uint64(eCollection.PackPoints()) {
-				if highestPackPointsCard == nil {
-					panic("No highest pack point card")
-				}
+// Takes one step towards completing the collection, either redeeming a card
+// with pack points or opening a booster, which is returned. Nothing is done
+// when out of SimOptions.PackBudget.
+func (s *expansionSimulator) step(randomGenerator *rand.Rand) (opened *data.BoosterInstance, outOfBudget bool) {
+	e := s.expansion
+	eCollection := s.eCollection
+	eSimRun := s.run
+	options := s.options
+	missing := eCollection.Missing()
+	weights, isWeighted := options.BoosterWeights(e.Id())
+	targets := missing
+	if options.wishlist != nil {
+		targets = options.wishlist.Wanted(eCollection)
+	}
 
-				eCollection.AcquireCardUsingPackPoints(highestPackPointsCard)
-				eSimRun.numCardsObtainedFromPackPoints += 1
-				continue
-			}
+	// Decide, should we trade in pack points or pick a booster?
+	// TODO: Can exit early, but needs some careful thought on exact conditions
+	var highestPackPointsCard *data.Card
+	var packPointsToObtainAllMissing uint64 = 0
+	for card := range e.CardsIn(targets) {
+		// When weighted, only weighted cards need completing
+		if !isWeighted || weights[card.Number()] > 0 {
+			packPointsToObtainAllMissing += uint64(card.Rarity().PackPointsToObtain()) * uint64(s.copiesToObtain(card))
+		}
+		if highestPackPointsCard == nil || isBetterRedemption(card, highestPackPointsCard, weights) {
+			highestPackPointsCard = card
+		}
+	}
 
-			// Not enough pack points, now we choose a booster instead.
-			simBooster, sErr := e.GetHighestOfferingBoosterForMissingCards(
-				missing,
-			)
-			if sErr != nil {
-				fmt.Printf("No missing %v %v\n", e.Id(), missing)
-				panic("should be able to find booster for missing number")
-			}
+	// We have max pack points, use some now so can continue to accrue
+	if eCollection.PackPointPool().IsFull() {
+		if highestPackPointsCard == nil {
+			panic("No highest pack point card")
+		}
 
-			boosterInstance := simBooster.CreateRandomInstance(randomGenerator)
-			eCollection.AcquireCardsFromBooster(boosterInstance.Cards())
+		eCollection.AcquireCardUsingPackPoints(highestPackPointsCard)
+		eSimRun.numCardsObtainedFromPackPoints += 1
+		if options.recordAcquisitions {
+			eSimRun.recordAcquisition(highestPackPointsCard, AcquiredFromPackPoints)
+		}
+		return nil, false
+	}
 
-			eSimRun.numOpened++
-			eSimRun.totalPackPoints += packPointsPerOpening
-			if boosterInstance.IsRare() {
-				eSimRun.numRarePacks++
-			}
+	// We have enough pack points to complete our collection
+	if packPointsToObtainAllMissing > 0 && packPointsToObtainAllMissing <= uint64(eCollection.PackPoints()) {
+		if highestPackPointsCard == nil {
+			panic("No highest pack point card")
+		}
+
+		eCollection.AcquireCardUsingPackPoints(highestPackPointsCard)
+		eSimRun.numCardsObtainedFromPackPoints += 1
+		if options.recordAcquisitions {
+			eSimRun.recordAcquisition(highestPackPointsCard, AcquiredFromPackPoints)
 		}
+		return nil, false
 	}
 
-	return &SimRun{expansionRuns: expansionRuns}, nil
+	if options.packBudget > 0 && eSimRun.numOpened >= options.packBudget {
+		return nil, true
+	}
+
+	// Not enough pack points, now we choose a booster instead.
+	var simBooster *data.Booster
+	var sErr error
+	if isWeighted {
+		simBooster, sErr = e.GetHighestWeightedBoosterForMissingCards(targets, weights)
+	}
+	// Fall back when no booster offers a weighted card
+	if simBooster == nil {
+		simBooster, sErr = e.GetHighestOfferingBoosterForMissingCards(
+			targets,
+		)
+	}
+	if sErr != nil {
+		fmt.Printf("No missing %v %v\n", e.Id(), slices.Collect(targets.Numbers()))
+		panic("should be able to find booster for missing number")
+	}
+
+	boosterInstance := simBooster.CreateRandomInstance(randomGenerator)
+	s.newCards = s.newCards[:0]
+	for c := range boosterInstance.Cards() {
+		if missing.Contains(c) && !slices.Contains(s.newCards, c) {
+			s.newCards = append(s.newCards, c)
+		} else {
+			eSimRun.recordDuplicate(c.Rarity())
+		}
+	}
+	earned := eCollection.AcquireCardsFromBooster(boosterInstance.Cards())
+
+	eSimRun.numOpened++
+	if options.recordAcquisitions {
+		for _, c := range s.newCards {
+			eSimRun.recordAcquisition(c, AcquiredFromBooster)
+		}
+	}
+	eSimRun.totalPackPoints += uint64(earned)
+	if boosterInstance.IsRare() {
+		eSimRun.numRarePacks++
+	}
+	return boosterInstance, false
+}
+
+// Copies of a target card still to obtain, only more than one for wishlist
+// cards wanted several times.
+func (s *expansionSimulator) copiesToObtain(card *data.Card) uint8 {
+	if s.options.wishlist == nil {
+		return 1
+	}
+	return s.options.wishlist.CopiesWanted(s.expansion.Id(), card) - s.eCollection.Copies(card)
+}
+
+// Whether to spend pack points on card over current, preferring higher
+// weighted cards and then the most expensive. Weights are nil when unweighted.
+func isBetterRedemption(card *data.Card, current *data.Card, weights data.CardWeights) bool {
+	cardWeight := weights[card.Number()]
+	currentWeight := weights[current.Number()]
+	if cardWeight != currentWeight {
+		return cardWeight > currentWeight
+	}
+	return card.Rarity().PackPointsToObtain() > current.Rarity().PackPointsToObtain()
+}
+
+type simJob struct {
+	index           uint64
+	randomGenerator *rand.Rand
 }
 
+// Runs simulations on a pool of workers (GOMAXPROCS when workers is 0),
+// sending each run to results as it completes. Runs complete out of order,
+// but each run's seed only depends on randomSeed and its index, so the set of
+// results is identical for any number of workers. When ctx is cancelled no
+// new runs are started, runs still in progress are dropped and ctx's error
+// is returned.
 func RunAllSimulations(
 	expansions []*data.Expansion,
 	userCollection *userdata.UserCollection,
 	completePredicate ExpansionSimCompletePredicate,
+	options *SimOptions,
 	runs uint64,
 	randomSeed uint64,
+	workers int,
+	ctx context.Context,
+	results chan<- *SimRun,
+) error {
+	return runSimulationRange(
+		expansions,
+		userCollection,
+		completePredicate,
+		options,
+		0,
+		runs,
+		randomSeed,
+		workers,
+		ctx,
+		results,
+	)
+}
+
+// Runs simulations with indexes in [from, to), see RunAllSimulations.
+func runSimulationRange(
+	expansions []*data.Expansion,
+	userCollection *userdata.UserCollection,
+	completePredicate ExpansionSimCompletePredicate,
+	options *SimOptions,
+	from uint64,
+	to uint64,
+	randomSeed uint64,
+	workers int,
 	ctx context.Context,
 	results chan<- *SimRun,
 ) error {
-	if runs == 0 {
+	return runSeededJobs(from, to, randomSeed, workers, ctx, results, func(job simJob) (*SimRun, error) {
+		r, rErr := RunSim(
+			expansions,
+			userCollection,
+			completePredicate,
+			options,
+			job.randomGenerator,
+		)
+		if rErr != nil {
+			return nil, rErr
+		}
+		r.index = job.index
+		return r, nil
+	})
+}
+
+// Runs jobs with indexes in [from, to) on a pool of workers, seeding each
+// job's random generator by its index. See RunAllSimulations.
+func runSeededJobs[T any](
+	from uint64,
+	to uint64,
+	randomSeed uint64,
+	workers int,
+	ctx context.Context,
+	results chan<- T,
+	run func(simJob) (T, error),
+) error {
+	if to <= from {
 		return nil
 	}
-
-	// TODO: Problem with using same value twice?
-	rootRand := rand.New(rand.NewPCG(randomSeed, randomSeed))
-	simRands := make([]*rand.Rand, runs)
-	for i := range runs {
-		seed1 := rootRand.Uint64()
-		seed2 := rootRand.Uint64()
-		simRands[i] = rand.New(rand.NewPCG(seed1, seed2))
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
 	}
+	workers = int(min(uint64(workers), to-from))
 
-	g, _ := errgroup.WithContext(ctx)
+	g, gCtx := errgroup.WithContext(ctx)
 
-	for i := range runs {
-		runRand := simRands[i]
-		g.Go(func() error {
-			r, rErr := RunSim(
-				expansions,
-				userCollection,
-				completePredicate,
-				runRand,
-			)
-			if rErr != nil {
-				return rErr
+	// Each job's seed comes from its index alone, so results don't depend on
+	// scheduling or on which batch of a range the job is in.
+	jobs := make(chan simJob, workers)
+	g.Go(func() error {
+		defer close(jobs)
+		for i := from; i < to; i++ {
+			job := simJob{index: i, randomGenerator: rand.New(rand.NewPCG(randomSeed, i))}
+			select {
+			case jobs <- job:
+			case <-gCtx.Done():
+				return gCtx.Err()
 			}
+		}
+		return nil
+	})
+
+	for range workers {
+		g.Go(func() error {
+			for job := range jobs {
+				if gCtx.Err() != nil {
+					return gCtx.Err()
+				}
+
+				r, rErr := run(job)
+				if rErr != nil {
+					return rErr
+				}
 
-			results <- r
+				select {
+				case results <- r:
+				case <-gCtx.Done():
+					return gCtx.Err()
+				}
+			}
 			return nil
 		})
 	}
-	err := g.Wait()
-	if err != nil {
-		return err
-	}
 
-	return nil
+	return g.Wait()
 }
@@ -0,0 +1,390 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"ptcgpocket/data"
+	"ptcgpocket/internal/testexpansion"
+	"ptcgpocket/userdata"
+	"slices"
+	"testing"
+)
+
+func newEmptyTestCollection(expansions []*data.Expansion) *userdata.UserCollection {
+	expansionCollections := make(map[data.ExpansionId]*userdata.ExpansionCollection, len(expansions))
+	for _, e := range expansions {
+		expansionCollections[e.Id()] = userdata.NewExpansionCollection(e, e.AllCards(), 0)
+	}
+	return userdata.NewUserCollection(expansionCollections)
+}
+
+func isWholeExpansionComplete(e *data.Expansion, c *userdata.ExpansionCollection) bool {
+	return c.Missing().IsEmpty()
+}
+
+func TestRunSimCompletesCollection(t *testing.T) {
+	expansions := []*data.Expansion{testexpansion.GeneticApexShaped("test")}
+	collection := newEmptyTestCollection(expansions)
+
+	run, err := RunSim(expansions, collection, isWholeExpansionComplete, DefaultSimOptions, rand.New(rand.NewPCG(1, 2)))
+	if err != nil {
+		t.Fatalf("RunSim returned error %v", err)
+	}
+	if run.TotalPacksOpened() == 0 {
+		t.Errorf("RunSim packs opened = 0; want > 0")
+	}
+	missing, _ := collection.MissingSetForExpansion("test")
+	if missing.Len() != int(expansions[0].TotalCards()) {
+		t.Errorf("RunSim modified source collection, missing = %v; want %v", missing.Len(), expansions[0].TotalCards())
+	}
+}
+
+func BenchmarkRunSim(b *testing.B) {
+	expansions := []*data.Expansion{testexpansion.GeneticApexShaped("test")}
+	collection := newEmptyTestCollection(expansions)
+	randomGenerator := rand.New(rand.NewPCG(1, 2))
+	for b.Loop() {
+		_, err := RunSim(expansions, collection, isWholeExpansionComplete, DefaultSimOptions, randomGenerator)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func collectSimulations(t *testing.T, workers int) map[uint64]uint64 {
+	expansions := []*data.Expansion{testexpansion.GeneticApexShaped("test")}
+	collection := newEmptyTestCollection(expansions)
+	results := make(chan *SimRun, 20)
+	err := RunAllSimulations(expansions, collection, isWholeExpansionComplete, DefaultSimOptions, 20, 123, workers, context.Background(), results)
+	if err != nil {
+		t.Fatalf("RunAllSimulations returned error %v", err)
+	}
+	close(results)
+
+	opened := make(map[uint64]uint64)
+	for r := range results {
+		opened[r.Index()] = r.TotalPacksOpened()
+	}
+	return opened
+}
+
+func TestRunAllSimulationsSameForAnyWorkers(t *testing.T) {
+	single := collectSimulations(t, 1)
+	multiple := collectSimulations(t, 4)
+	if len(single) != 20 {
+		t.Fatalf("Single worker runs = %d; want 20", len(single))
+	}
+	if !maps.Equal(single, multiple) {
+		t.Errorf("Results differ between 1 and 4 workers: %v vs %v", single, multiple)
+	}
+}
+
+func TestSimulationRangeSeededByIndex(t *testing.T) {
+	expansions := []*data.Expansion{testexpansion.GeneticApexShaped("test")}
+	collection := newEmptyTestCollection(expansions)
+	results := make(chan *SimRun, 5)
+	err := runSimulationRange(expansions, collection, isWholeExpansionComplete, DefaultSimOptions, 15, 20, 123, 2, context.Background(), results)
+	if err != nil {
+		t.Fatalf("runSimulationRange returned error %v", err)
+	}
+	close(results)
+
+	all := collectSimulations(t, 1)
+	for r := range results {
+		if r.TotalPacksOpened() != all[r.Index()] {
+			t.Errorf("Run %v opened %v packs in a range; want %v as when run from 0", r.Index(), r.TotalPacksOpened(), all[r.Index()])
+		}
+	}
+}
+
+func TestRunAllSimulationsCancelled(t *testing.T) {
+	expansions := []*data.Expansion{testexpansion.GeneticApexShaped("test")}
+	collection := newEmptyTestCollection(expansions)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := make(chan *SimRun)
+	err := RunAllSimulations(expansions, collection, isWholeExpansionComplete, DefaultSimOptions, 1_000_000, 1, 2, ctx, results)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("RunAllSimulations error = %v; want context.Canceled", err)
+	}
+}
+
+func TestRunningStat(t *testing.T) {
+	stat := &RunningStat{}
+	for _, v := range []float64{2, 4, 4, 4, 5, 5, 7, 9} {
+		stat.Add(v)
+	}
+	if stat.Mean() != 5 {
+		t.Errorf("Mean = %v; want 5", stat.Mean())
+	}
+	if math.Abs(stat.Variance()-32.0/7.0) > 1e-9 {
+		t.Errorf("Variance = %v; want %v", stat.Variance(), 32.0/7.0)
+	}
+}
+
+func TestDistributionPercentile(t *testing.T) {
+	distribution := &Distribution{}
+	for _, v := range []uint64{9, 1, 5, 3, 7} {
+		distribution.Add(v)
+	}
+	if p, known := distribution.Percentile(50); p != 5 || !known {
+		t.Errorf("Percentile(50) = %v, %v; want 5, true", p, known)
+	}
+	if p, known := distribution.Percentile(95); p != 9 || !known {
+		t.Errorf("Percentile(95) = %v, %v; want 9, true", p, known)
+	}
+
+	// Censored values rank above the rest
+	for range 5 {
+		distribution.AddCensored()
+	}
+	if p, known := distribution.Percentile(50); p != 9 || !known {
+		t.Errorf("Percentile(50) with censored = %v, %v; want 9, true", p, known)
+	}
+	if _, known := distribution.Percentile(75); known {
+		t.Errorf("Percentile(75) with censored known = true; want false")
+	}
+}
+
+func TestRunAdaptiveSimulations(t *testing.T) {
+	expansions := []*data.Expansion{testexpansion.GeneticApexShaped("test")}
+	collection := newEmptyTestCollection(expansions)
+
+	runAdaptive := func() *SimPrecision {
+		results := make(chan *SimRun, 1_000)
+		precision, err := RunAdaptiveSimulations(
+			expansions,
+			collection,
+			isWholeExpansionComplete,
+			DefaultSimOptions,
+			42,
+			0,
+			NewAdaptiveOptions(100, 10, 0),
+			context.Background(),
+			results,
+		)
+		if err != nil {
+			t.Fatalf("RunAdaptiveSimulations returned error %v", err)
+		}
+		return precision
+	}
+
+	precision := runAdaptive()
+	if !precision.Converged() {
+		t.Errorf("Converged = false; want true")
+	}
+	if precision.Runs()%10 != 0 {
+		t.Errorf("Runs = %d; want a multiple of the batch size", precision.Runs())
+	}
+	if precision.TotalStat().ConfidenceHalfWidth95() > 100 {
+		t.Errorf("Half width = %v; want <= 100", precision.TotalStat().ConfidenceHalfWidth95())
+	}
+	if runAdaptive().Runs() != precision.Runs() {
+		t.Errorf("Runs not reproducible for the same seed")
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	expansions := []*data.Expansion{testexpansion.GeneticApexShaped("test")}
+	collection := newEmptyTestCollection(expansions)
+	timeline := NewTimeline(expansions, collection)
+	randomGenerator := rand.New(rand.NewPCG(3, 4))
+	for range 5 {
+		run, err := RunSim(expansions, collection, isWholeExpansionComplete, NewSimOptions(true, 0, nil), randomGenerator)
+		if err != nil {
+			t.Fatalf("RunSim returned error %v", err)
+		}
+		for _, eRun := range run.ExpansionRuns() {
+			numAcquisitions := 0
+			for range eRun.Acquisitions() {
+				numAcquisitions++
+			}
+			if numAcquisitions != int(expansions[0].TotalCards()) {
+				t.Errorf("Acquisitions = %d; want %d", numAcquisitions, expansions[0].TotalCards())
+			}
+		}
+		timeline.Add(run)
+	}
+
+	et := timeline.Expansion(expansions[0])
+	if et.CompletionAfter(0) != 0 {
+		t.Errorf("Completion after 0 packs = %v; want 0", et.CompletionAfter(0))
+	}
+	if et.CompletionAfter(et.MaxPacks()) != 100 {
+		t.Errorf("Completion after max packs = %v; want 100", et.CompletionAfter(et.MaxPacks()))
+	}
+	for ct := range et.Cards() {
+		if ct.PacksUntil().Count() != 5 {
+			t.Errorf("Card %v obtained in %d runs; want 5", ct.Card().Number(), ct.PacksUntil().Count())
+		}
+	}
+	crown, _ := expansions[0].GetCardByNumber(278)
+	first, _ := expansions[0].GetCardByNumber(1)
+	crownTimeline, _ := et.Card(crown)
+	firstTimeline, _ := et.Card(first)
+	if crownTimeline.PacksUntil().Mean() <= firstTimeline.PacksUntil().Mean() {
+		t.Errorf("Crown mean packs until = %v; want more than a one diamond's %v", crownTimeline.PacksUntil().Mean(), firstTimeline.PacksUntil().Mean())
+	}
+}
+
+func TestBudgetEstimatesAgree(t *testing.T) {
+	expansions := []*data.Expansion{testexpansion.GeneticApexShaped("test")}
+	collection := newEmptyTestCollection(expansions)
+	missing, _ := collection.MissingSetForExpansion("test")
+	targets := data.NewCardSet()
+	for c := range expansions[0].Cards() {
+		if c.Rarity() == data.RarityOneDiamond {
+			targets.Add(c)
+		}
+	}
+
+	analytic, _, aErr := EstimateBudgetAnalytically(expansions[0], missing, targets, 60)
+	if aErr != nil {
+		t.Fatalf("EstimateBudgetAnalytically returned error %v", aErr)
+	}
+	simulated, sErr := SimulateBudget(expansions[0], collection, targets, 60, 400, 9, 0, context.Background())
+	if sErr != nil {
+		t.Fatalf("SimulateBudget returned error %v", sErr)
+	}
+
+	if simulated.Runs() != 400 {
+		t.Errorf("Simulated runs = %d; want 400", simulated.Runs())
+	}
+	analyticMissing := analytic.ExpectedMissing(data.RarityOneDiamond)
+	simulatedMissing := simulated.ExpectedMissing(data.RarityOneDiamond)
+	if math.Abs(analyticMissing-simulatedMissing) > 0.5 {
+		t.Errorf("Expected missing analytic %v vs simulated %v; want within 0.5", analyticMissing, simulatedMissing)
+	}
+	if math.Abs(analytic.CompletionProbability()-simulated.CompletionProbability()) > 0.1 {
+		t.Errorf("Completion analytic %v vs simulated %v; want within 0.1", analytic.CompletionProbability(), simulated.CompletionProbability())
+	}
+	if simulated.ExpectedMissing(data.RarityCrown) != 0 {
+		t.Errorf("Expected missing crowns = %v; want 0 as not targeted", simulated.ExpectedMissing(data.RarityCrown))
+	}
+}
+
+func TestSimulateBudgetOpensTargetBooster(t *testing.T) {
+	var common, special []*data.Card
+	for n := range data.ExpansionCardNumber(100) {
+		common = append(common, data.NewCard(data.NewBaseCard("Common", 60, 1), n+1, data.RarityOneDiamond))
+	}
+	target := data.NewCard(data.NewBaseCard("Target", 60, 1), 101, data.RarityOneDiamond)
+	owned := data.NewCard(data.NewBaseCard("Owned", 60, 1), 102, data.RarityOneDiamond)
+	special = append(special, target, owned)
+	var boosters []*data.Booster
+	for _, cards := range [][]*data.Card{common, special} {
+		b, err := data.NewBooster(
+			"Test booster",
+			cards,
+			data.OfferingRatesTable{data.RarityOneDiamond: *data.NewBoosterOffering(100.0, 100.0, 100.0, 100.0)},
+			0,
+			0.9995,
+			0,
+			0.0005,
+			data.DefaultBoosterOptions,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		boosters = append(boosters, b)
+	}
+	expansion := data.NewExpansion("test", "test", "test", boosters)
+	missing := data.NewCardSet(append(common, target)...)
+	collection := userdata.NewUserCollection(map[data.ExpansionId]*userdata.ExpansionCollection{
+		"test": userdata.NewExpansionCollection(expansion, missing, 0),
+	})
+	targets := data.NewCardSet(target)
+
+	// The booster with the most missing cards never offers the target
+	analytic, booster, aErr := EstimateBudgetAnalytically(expansion, missing, targets, 10)
+	if aErr != nil {
+		t.Fatalf("EstimateBudgetAnalytically returned error %v", aErr)
+	}
+	if booster != boosters[1] {
+		t.Errorf("EstimateBudgetAnalytically booster = %v; want the booster offering the target", booster.Name())
+	}
+	simulated, sErr := SimulateBudget(expansion, collection, targets, 10, 100, 9, 0, context.Background())
+	if sErr != nil {
+		t.Fatalf("SimulateBudget returned error %v", sErr)
+	}
+	if math.Abs(analytic.CompletionProbability()-simulated.CompletionProbability()) > 0.05 {
+		t.Errorf("Completion analytic %v vs simulated %v; want within 0.05", analytic.CompletionProbability(), simulated.CompletionProbability())
+	}
+}
+
+func TestRunSimWishlist(t *testing.T) {
+	expansion := testexpansion.GeneticApexShaped("test")
+	expansions := []*data.Expansion{expansion}
+	collection := newEmptyTestCollection(expansions)
+	// A common and a crown
+	common, _ := expansion.GetCardByNumber(1)
+	crown, _ := expansion.GetCardByNumber(data.ExpansionCardNumber(expansion.TotalCards()))
+	wishlist := userdata.NewWishlist("deck", map[data.ExpansionId]*userdata.ExpansionWishlist{
+		"test": userdata.NewExpansionWishlist([]*data.Card{common, crown}, nil, nil),
+	})
+
+	run, err := RunSim(
+		expansions,
+		collection,
+		NewWishlistCompletePredicate(wishlist),
+		NewSimOptions(false, 0, WishlistBoosterWeights(expansions, wishlist)).WithWishlist(wishlist),
+		rand.New(rand.NewPCG(1, 2)),
+	)
+	if err != nil {
+		t.Fatalf("RunSim returned error %v", err)
+	}
+	for e, eRun := range run.ExpansionRuns() {
+		if !eRun.Completed() {
+			t.Errorf("%v completed = false; want true", e.Id())
+		}
+		if eRun.Missing().Contains(common) || eRun.Missing().Contains(crown) {
+			t.Errorf("%v wishlist cards still missing", e.Id())
+		}
+		if eRun.Missing().IsEmpty() {
+			t.Errorf("%v missing is empty; want only wishlist cards collected", e.Id())
+		}
+	}
+}
+
+func TestRunSimWishlistCopies(t *testing.T) {
+	expansion := testexpansion.GeneticApexShaped("test")
+	expansions := []*data.Expansion{expansion}
+	common, _ := expansion.GetCardByNumber(1)
+	crown, _ := expansion.GetCardByNumber(data.ExpansionCardNumber(expansion.TotalCards()))
+	// Own a copy of each, the wishlist wants a second
+	missing := data.NewCardSet(slices.Collect(expansion.Cards())...)
+	missing.Remove(common)
+	missing.Remove(crown)
+	collection := userdata.NewUserCollection(map[data.ExpansionId]*userdata.ExpansionCollection{
+		"test": userdata.NewExpansionCollection(expansion, missing, 0),
+	})
+	wishlist := userdata.NewWishlist("deck", map[data.ExpansionId]*userdata.ExpansionWishlist{
+		"test": userdata.NewExpansionWishlist(
+			[]*data.Card{common, crown},
+			nil,
+			map[data.ExpansionCardNumber]uint8{common.Number(): 2, crown.Number(): 2},
+		),
+	})
+
+	run, err := RunSim(
+		expansions,
+		collection,
+		NewWishlistCompletePredicate(wishlist),
+		NewSimOptions(false, 0, WishlistBoosterWeights(expansions, wishlist)).WithWishlist(wishlist),
+		rand.New(rand.NewPCG(1, 2)),
+	)
+	if err != nil {
+		t.Fatalf("RunSim returned error %v", err)
+	}
+	eRun, eRunFound := run.expansionRuns[expansion]
+	if !eRunFound || !eRun.Completed() {
+		t.Fatalf("Wishlist run = %v; want a completed run for the second copies", eRun)
+	}
+	if eRun.NumOpened()+eRun.NumCardsObtainedFromPackPoints() == 0 {
+		t.Errorf("Wishlist run obtained nothing; want the second copies obtained")
+	}
+}
@@ -0,0 +1,100 @@
+package sim
+
+import (
+	"math"
+	"slices"
+)
+
+// z score for a two sided 95% confidence interval.
+const ConfidenceZ95 = 1.959964
+
+// Mean and variance of a stream of values, using Welford's algorithm so
+// runs can be added one at a time without keeping them all.
+type RunningStat struct {
+	count uint64
+	mean  float64
+	m2    float64
+}
+
+func (s *RunningStat) Add(value float64) {
+	s.count++
+	delta := value - s.mean
+	s.mean += delta / float64(s.count)
+	s.m2 += delta * (value - s.mean)
+}
+
+func (s *RunningStat) Count() uint64 {
+	return s.count
+}
+
+func (s *RunningStat) Mean() float64 {
+	return s.mean
+}
+
+// Sample variance, 0 with fewer than 2 values.
+func (s *RunningStat) Variance() float64 {
+	if s.count < 2 {
+		return 0
+	}
+	return s.m2 / float64(s.count-1)
+}
+
+func (s *RunningStat) StdDev() float64 {
+	return math.Sqrt(s.Variance())
+}
+
+// Half the width of the 95% confidence interval of the mean, infinite with
+// fewer than 2 values.
+func (s *RunningStat) ConfidenceHalfWidth95() float64 {
+	if s.count < 2 {
+		return math.Inf(1)
+	}
+	return ConfidenceZ95 * s.StdDev() / math.Sqrt(float64(s.count))
+}
+
+// Every value added, for percentiles. Unlike RunningStat this keeps all the
+// values so is only for per run totals.
+type Distribution struct {
+	values []uint64
+	sorted bool
+	// Values only known to be above every added value, such as runs that
+	// ran out of SimOptions.PackBudget
+	censored int
+}
+
+func (d *Distribution) Add(value uint64) {
+	d.values = append(d.values, value)
+	d.sorted = false
+}
+
+// Adds a value only known to be above every added value.
+func (d *Distribution) AddCensored() {
+	d.censored++
+}
+
+// Number of values, including censored ones.
+func (d *Distribution) Count() int {
+	return len(d.values) + d.censored
+}
+
+func (d *Distribution) Censored() int {
+	return d.censored
+}
+
+// Nearest rank percentile, 0 when empty. Not known when the rank falls among
+// the censored values.
+func (d *Distribution) Percentile(percent float64) (uint64, bool) {
+	count := d.Count()
+	if count == 0 {
+		return 0, true
+	}
+	if !d.sorted {
+		slices.Sort(d.values)
+		d.sorted = true
+	}
+	rank := min(max(int(math.Ceil(percent/100*float64(count))), 1), count)
+	if rank > len(d.values) {
+		return 0, false
+	}
+	return d.values[rank-1], true
+}
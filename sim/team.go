@@ -0,0 +1,273 @@
+package sim
+
+import (
+	"cmp"
+	"context"
+	"iter"
+	"math/rand/v2"
+	"ptcgpocket/data"
+	"ptcgpocket/userdata"
+	"slices"
+)
+
+// A player's simulation of an expansion when trading in a team, and on their
+// own.
+type TeamPlayerRun struct {
+	name           string
+	team           *ExpansionSimRun
+	solo           *ExpansionSimRun
+	tradesReceived uint64
+}
+
+func (r *TeamPlayerRun) Name() string {
+	return r.name
+}
+
+// Packs opened until complete when trading in the team.
+func (r *TeamPlayerRun) TeamPacks() uint64 {
+	return r.team.numOpened
+}
+
+// Packs opened until complete on their own.
+func (r *TeamPlayerRun) SoloPacks() uint64 {
+	return r.solo.numOpened
+}
+
+func (r *TeamPlayerRun) TradesReceived() uint64 {
+	return r.tradesReceived
+}
+
+type TeamExpansionRun struct {
+	expansion *data.Expansion
+	players   []*TeamPlayerRun
+}
+
+func (r *TeamExpansionRun) Expansion() *data.Expansion {
+	return r.expansion
+}
+
+// Players in profile name order.
+func (r *TeamExpansionRun) Players() iter.Seq[*TeamPlayerRun] {
+	return slices.Values(r.players)
+}
+
+// Packs opened by the player last to complete when trading.
+func (r *TeamExpansionRun) TeamGroupPacks() uint64 {
+	var most uint64
+	for _, p := range r.players {
+		most = max(most, p.TeamPacks())
+	}
+	return most
+}
+
+// Packs opened by the player last to complete on their own.
+func (r *TeamExpansionRun) SoloGroupPacks() uint64 {
+	var most uint64
+	for _, p := range r.players {
+		most = max(most, p.SoloPacks())
+	}
+	return most
+}
+
+type TeamSimRun struct {
+	index         uint64
+	expansionRuns []*TeamExpansionRun
+}
+
+// Position of the run within RunAllTeamSimulations, which determines its
+// seed.
+func (r *TeamSimRun) Index() uint64 {
+	return r.index
+}
+
+// Expansions in the order simulated.
+func (r *TeamSimRun) ExpansionRuns() iter.Seq[*TeamExpansionRun] {
+	return slices.Values(r.expansionRuns)
+}
+
+// A player's progress through an expansion in a team simulation.
+type teamPlayer struct {
+	simulator *expansionSimulator
+	done      bool
+	// Extra copies of tradeable cards, by number as boosters sharing a card
+	// can have their own copy of it
+	spare          map[data.ExpansionCardNumber]uint8
+	tradesReceived uint64
+}
+
+func (p *teamPlayer) missing() *data.CardSet {
+	return p.simulator.eCollection.Missing()
+}
+
+// Cards of the rarity the player can give the other player, in number order.
+func (p *teamPlayer) offers(other *teamPlayer, rarity *data.Rarity) []*data.Card {
+	var cards []*data.Card
+	for number, n := range p.spare {
+		if n == 0 || !other.missing().ContainsNumber(number) {
+			continue
+		}
+		if c, _ := p.simulator.expansion.GetCardByNumber(number); c.Rarity() == rarity {
+			cards = append(cards, c)
+		}
+	}
+	slices.SortFunc(cards, func(c1, c2 *data.Card) int {
+		return cmp.Compare(c1.Number(), c2.Number())
+	})
+	return cards
+}
+
+// Records the spare copies of an opened pack, every card that wasn't new to
+// the collection when opened.
+func (p *teamPlayer) addSpares(opened *data.BoosterInstance) {
+	// New cards are in the order they first appear in the pack
+	newCards := p.simulator.newCards
+	firstCopies := 0
+	for c := range opened.Cards() {
+		if firstCopies < len(newCards) && newCards[firstCopies] == c {
+			firstCopies++
+			continue
+		}
+		if c.Rarity().IsTradeable() {
+			p.spare[c.Number()]++
+		}
+	}
+}
+
+// Trades between each pair of players, one for one of the same tradeable
+// rarity, as many times as both can give a card the other is missing.
+func tradeSession(players []*teamPlayer) {
+	for i, p1 := range players {
+		for _, p2 := range players[i+1:] {
+			for r := range data.Rarities.All() {
+				if !r.IsTradeable() {
+					continue
+				}
+				offers1 := p1.offers(p2, r)
+				if len(offers1) == 0 {
+					continue
+				}
+				offers2 := p2.offers(p1, r)
+				for t := range min(len(offers1), len(offers2)) {
+					p1.spare[offers1[t].Number()]--
+					p2.simulator.eCollection.AcquireCardFromTrade(offers1[t])
+					p2.tradesReceived++
+					p2.spare[offers2[t].Number()]--
+					p1.simulator.eCollection.AcquireCardFromTrade(offers2[t])
+					p1.tradesReceived++
+				}
+			}
+		}
+	}
+}
+
+// Simulates the profiles each opening a pack a round, following RunSim's
+// strategy, and trading duplicates with each other every tradeEvery rounds.
+// Each player is also simulated on their own for comparison. Players that
+// have completed an expansion keep trading their duplicates of it.
+func RunTeamSim(
+	expansions []*data.Expansion,
+	profiles *userdata.Profiles,
+	expansionCompletePredicate ExpansionSimCompletePredicate,
+	options *SimOptions,
+	tradeEvery uint64,
+	randomGenerator *rand.Rand,
+) (*TeamSimRun, error) {
+	var names []string
+	var collections []*userdata.UserCollection
+	var soloRuns []*SimRun
+	for name, userData := range profiles.All() {
+		// Profiles can leave out expansions, which they don't take part in
+		profileExpansions := slices.DeleteFunc(slices.Clone(expansions), func(e *data.Expansion) bool {
+			return userData.Collection().GetExpansionCollection(e.Id()) == nil
+		})
+		solo, sErr := RunSim(profileExpansions, userData.Collection(), expansionCompletePredicate, options, randomGenerator)
+		if sErr != nil {
+			return nil, sErr
+		}
+		names = append(names, name)
+		collections = append(collections, userData.Collection().Clone())
+		soloRuns = append(soloRuns, solo)
+	}
+
+	teamRun := &TeamSimRun{}
+	for _, e := range expansions {
+		eRun := &TeamExpansionRun{expansion: e}
+		var players []*teamPlayer
+		for i, collection := range collections {
+			eCollection := collection.GetExpansionCollection(e.Id())
+			if eCollection == nil {
+				continue
+			}
+			p := &teamPlayer{
+				simulator: newExpansionSimulator(e, eCollection, options),
+				spare:     make(map[data.ExpansionCardNumber]uint8),
+			}
+			for c := range e.Cards() {
+				if copies := eCollection.Copies(c); copies > 1 && c.Rarity().IsTradeable() {
+					p.spare[c.Number()] = copies - 1
+				}
+			}
+			players = append(players, p)
+
+			solo, soloFound := soloRuns[i].expansionRuns[e]
+			if !soloFound {
+				solo = &ExpansionSimRun{completed: true}
+			}
+			eRun.players = append(eRun.players, &TeamPlayerRun{name: names[i], team: p.simulator.run, solo: solo})
+		}
+
+		for round := uint64(1); slices.ContainsFunc(players, func(p *teamPlayer) bool { return !p.done }); round++ {
+			for _, p := range players {
+				for !p.done {
+					if expansionCompletePredicate(e, p.simulator.eCollection) {
+						p.simulator.run.completed = true
+						p.done = true
+						break
+					}
+					opened, outOfBudget := p.simulator.step(randomGenerator)
+					if outOfBudget {
+						p.done = true
+						break
+					}
+					if opened != nil {
+						p.addSpares(opened)
+						break
+					}
+				}
+			}
+			if tradeEvery > 0 && round%tradeEvery == 0 {
+				tradeSession(players)
+			}
+		}
+
+		for i, p := range players {
+			p.simulator.run.missing = p.missing().Clone()
+			eRun.players[i].tradesReceived = p.tradesReceived
+		}
+		teamRun.expansionRuns = append(teamRun.expansionRuns, eRun)
+	}
+	return teamRun, nil
+}
+
+// Runs team simulations on a pool of workers, see RunAllSimulations.
+func RunAllTeamSimulations(
+	expansions []*data.Expansion,
+	profiles *userdata.Profiles,
+	completePredicate ExpansionSimCompletePredicate,
+	options *SimOptions,
+	tradeEvery uint64,
+	runs uint64,
+	randomSeed uint64,
+	workers int,
+	ctx context.Context,
+	results chan<- *TeamSimRun,
+) error {
+	return runSeededJobs(0, runs, randomSeed, workers, ctx, results, func(job simJob) (*TeamSimRun, error) {
+		r, rErr := RunTeamSim(expansions, profiles, completePredicate, options, tradeEvery, job.randomGenerator)
+		if rErr != nil {
+			return nil, rErr
+		}
+		r.index = job.index
+		return r, nil
+	})
+}
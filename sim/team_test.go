@@ -0,0 +1,83 @@
+package sim
+
+import (
+	"math/rand/v2"
+	"ptcgpocket/data"
+	"ptcgpocket/internal/testexpansion"
+	"ptcgpocket/userdata"
+	"slices"
+	"testing"
+)
+
+func TestRunTeamSimTradingShortensCompletion(t *testing.T) {
+	expansions := []*data.Expansion{testexpansion.GeneticApexShaped("test")}
+	profiles := userdata.NewProfiles(map[string]*userdata.UserData{
+		"alex": userdata.NewUserData(newEmptyTestCollection(expansions), nil),
+		"sam":  userdata.NewUserData(newEmptyTestCollection(expansions), nil),
+	})
+	nonSecretComplete := func(e *data.Expansion, c *userdata.ExpansionCollection) bool {
+		return !c.Missing().Intersects(e.NonSecretCards())
+	}
+
+	var teamPacks, soloPacks, trades uint64
+	randomGenerator := rand.New(rand.NewPCG(5, 5))
+	for range 10 {
+		r, err := RunTeamSim(expansions, profiles, nonSecretComplete, NewSimOptions(false, 0, nil), 1, randomGenerator)
+		if err != nil {
+			t.Fatalf("RunTeamSim returned error %v", err)
+		}
+		for eRun := range r.ExpansionRuns() {
+			teamPacks += eRun.TeamGroupPacks()
+			soloPacks += eRun.SoloGroupPacks()
+			for p := range eRun.Players() {
+				trades += p.TradesReceived()
+			}
+		}
+	}
+	if trades == 0 {
+		t.Errorf("Trades received = 0; want some")
+	}
+	if teamPacks >= soloPacks {
+		t.Errorf("Team packs = %v; want fewer than solo packs %v", teamPacks, soloPacks)
+	}
+
+	r, err := RunTeamSim(expansions, profiles, nonSecretComplete, NewSimOptions(false, 0, nil), 0, randomGenerator)
+	if err != nil {
+		t.Fatalf("RunTeamSim returned error %v", err)
+	}
+	for eRun := range r.ExpansionRuns() {
+		for p := range eRun.Players() {
+			if p.TradesReceived() != 0 {
+				t.Errorf("%v trades received without trading = %v; want 0", p.Name(), p.TradesReceived())
+			}
+		}
+	}
+}
+
+func TestRunTeamSimPartialProfile(t *testing.T) {
+	var expansions []*data.Expansion
+	for _, id := range []data.ExpansionId{"first", "second"} {
+		var cards []*data.Card
+		for n := range data.ExpansionCardNumber(5) {
+			cards = append(cards, data.NewCard(data.NewBaseCard("Test", 60, 1), n+1, data.RarityOneDiamond))
+		}
+		expansions = append(expansions, testexpansion.New(id, cards))
+	}
+	// Sam hasn't started the second expansion's collection
+	profiles := userdata.NewProfiles(map[string]*userdata.UserData{
+		"alex": userdata.NewUserData(newEmptyTestCollection(expansions), nil),
+		"sam":  userdata.NewUserData(newEmptyTestCollection(expansions[:1]), nil),
+	})
+
+	r, err := RunTeamSim(expansions, profiles, isWholeExpansionComplete, DefaultSimOptions, 1, rand.New(rand.NewPCG(5, 5)))
+	if err != nil {
+		t.Fatalf("RunTeamSim returned error %v", err)
+	}
+	var players []int
+	for eRun := range r.ExpansionRuns() {
+		players = append(players, len(slices.Collect(eRun.Players())))
+	}
+	if !slices.Equal(players, []int{2, 1}) {
+		t.Errorf("Players per expansion = %v; want [2 1]", players)
+	}
+}
@@ -0,0 +1,206 @@
+package sim
+
+import (
+	"iter"
+	"maps"
+	"ptcgpocket/data"
+	"ptcgpocket/userdata"
+	"slices"
+)
+
+type AcquisitionSource uint8
+
+const (
+	AcquiredFromBooster AcquisitionSource = iota
+	AcquiredFromPackPoints
+)
+
+func (s AcquisitionSource) String() string {
+	switch s {
+	case AcquiredFromBooster:
+		return "booster"
+	case AcquiredFromPackPoints:
+		return "pack points"
+	}
+	return "unknown"
+}
+
+type CardAcquisition struct {
+	card *data.Card
+	// Number of packs opened in the expansion when the card was obtained,
+	// including the pack it came from.
+	packNumber uint64
+	source     AcquisitionSource
+}
+
+func (a *CardAcquisition) Card() *data.Card {
+	return a.card
+}
+
+func (a *CardAcquisition) PackNumber() uint64 {
+	return a.packNumber
+}
+
+func (a *CardAcquisition) Source() AcquisitionSource {
+	return a.source
+}
+
+func (r *ExpansionSimRun) recordAcquisition(card *data.Card, source AcquisitionSource) {
+	r.acquisitions = append(r.acquisitions, &CardAcquisition{
+		card:       card,
+		packNumber: r.numOpened,
+		source:     source,
+	})
+}
+
+type CardTimeline struct {
+	card           *data.Card
+	packsUntil     RunningStat
+	fromPackPoints uint64
+}
+
+func (t *CardTimeline) Card() *data.Card {
+	return t.card
+}
+
+// Packs opened in the expansion until the card was obtained, over the runs
+// it was obtained in.
+func (t *CardTimeline) PacksUntil() *RunningStat {
+	return &t.packsUntil
+}
+
+// Number of runs the card was obtained with pack points.
+func (t *CardTimeline) FromPackPoints() uint64 {
+	return t.fromPackPoints
+}
+
+type ExpansionTimeline struct {
+	expansion    *data.Expansion
+	initialOwned uint64
+	runs         uint64
+	cards        map[data.ExpansionCardNumber]*CardTimeline
+	// Cards obtained over all runs, indexed by pack number
+	acquiredAtPack []uint64
+}
+
+func (t *ExpansionTimeline) Expansion() *data.Expansion {
+	return t.expansion
+}
+
+func (t *ExpansionTimeline) Runs() uint64 {
+	return t.runs
+}
+
+// Timelines for cards missing at the start, in number order.
+func (t *ExpansionTimeline) Cards() iter.Seq[*CardTimeline] {
+	return func(yield func(*CardTimeline) bool) {
+		for _, n := range slices.Sorted(maps.Keys(t.cards)) {
+			if !yield(t.cards[n]) {
+				return
+			}
+		}
+	}
+}
+
+func (t *ExpansionTimeline) Card(card *data.Card) (*CardTimeline, bool) {
+	c, found := t.cards[card.Number()]
+	return c, found
+}
+
+// Most packs any run opened in the expansion.
+func (t *ExpansionTimeline) MaxPacks() uint64 {
+	if len(t.acquiredAtPack) == 0 {
+		return 0
+	}
+	return uint64(len(t.acquiredAtPack) - 1)
+}
+
+// Expected number of cards owned after opening the given number of packs.
+func (t *ExpansionTimeline) ExpectedOwnedAfter(packs uint64) float64 {
+	if t.runs == 0 {
+		return float64(t.initialOwned)
+	}
+	var acquired uint64
+	for p, a := range t.acquiredAtPack {
+		if uint64(p) > packs {
+			break
+		}
+		acquired += a
+	}
+	return float64(t.initialOwned) + float64(acquired)/float64(t.runs)
+}
+
+// Expected percentage of the expansion owned after opening the given number
+// of packs.
+func (t *ExpansionTimeline) CompletionAfter(packs uint64) float64 {
+	return 100.0 * t.ExpectedOwnedAfter(packs) / float64(t.expansion.TotalCards())
+}
+
+// Packs needed for the expected completion to reach the percentage, false
+// if it never did within the simulations.
+func (t *ExpansionTimeline) PacksToReach(percent float64) (uint64, bool) {
+	for p := range t.MaxPacks() + 1 {
+		if t.CompletionAfter(p) >= percent {
+			return p, true
+		}
+	}
+	return 0, false
+}
+
+// Aggregates acquisitions recorded by simulation runs into per card and
+// completion curve expectations.
+type Timeline struct {
+	expansions map[*data.Expansion]*ExpansionTimeline
+}
+
+func NewTimeline(expansions []*data.Expansion, userCollection *userdata.UserCollection) *Timeline {
+	timelines := make(map[*data.Expansion]*ExpansionTimeline, len(expansions))
+	for _, e := range expansions {
+		et := &ExpansionTimeline{
+			expansion:    e,
+			initialOwned: uint64(e.TotalCards()),
+			cards:        make(map[data.ExpansionCardNumber]*CardTimeline),
+		}
+		if missing, mFound := userCollection.MissingSetForExpansion(e.Id()); mFound {
+			et.initialOwned -= uint64(missing.Len())
+			for c := range e.CardsIn(missing) {
+				et.cards[c.Number()] = &CardTimeline{card: c}
+			}
+		}
+		timelines[e] = et
+	}
+	return &Timeline{expansions: timelines}
+}
+
+func (t *Timeline) Add(run *SimRun) {
+	for e, et := range t.expansions {
+		et.runs++
+		eRun, eFound := run.expansionRuns[e]
+		if !eFound {
+			continue
+		}
+		for _, a := range eRun.acquisitions {
+			for uint64(len(et.acquiredAtPack)) <= a.packNumber {
+				et.acquiredAtPack = append(et.acquiredAtPack, 0)
+			}
+			et.acquiredAtPack[a.packNumber]++
+
+			ct, ctFound := et.cards[a.card.Number()]
+			if !ctFound {
+				continue
+			}
+			ct.packsUntil.Add(float64(a.packNumber))
+			if a.source == AcquiredFromPackPoints {
+				ct.fromPackPoints++
+			}
+		}
+		// Keep the curve running until the last pack opened
+		for uint64(len(et.acquiredAtPack)) <= eRun.numOpened {
+			et.acquiredAtPack = append(et.acquiredAtPack, 0)
+		}
+	}
+}
+
+func (t *Timeline) Expansion(e *data.Expansion) *ExpansionTimeline {
+	return t.expansions[e]
+}
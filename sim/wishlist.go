@@ -0,0 +1,29 @@
+package sim
+
+import (
+	"ptcgpocket/data"
+	"ptcgpocket/userdata"
+)
+
+// Expansions are complete once every copy wanted of the wishlist's cards is
+// owned, expansions without wishlist cards are complete from the start. Use
+// with SimOptions.WithWishlist so further copies of owned cards are targeted.
+func NewWishlistCompletePredicate(wishlist *userdata.Wishlist) ExpansionSimCompletePredicate {
+	return func(e *data.Expansion, eCollection *userdata.ExpansionCollection) bool {
+		return wishlist.IsComplete(eCollection)
+	}
+}
+
+// Booster weights steering simulations towards the wishlist's cards only.
+func WishlistBoosterWeights(
+	expansions []*data.Expansion,
+	wishlist *userdata.Wishlist,
+) map[data.ExpansionId]data.CardWeights {
+	boosterWeights := make(map[data.ExpansionId]data.CardWeights, len(expansions))
+	for _, e := range expansions {
+		if weights, wFound := wishlist.WeightsForExpansion(e.Id()); wFound {
+			boosterWeights[e.Id()] = weights
+		}
+	}
+	return boosterWeights
+}
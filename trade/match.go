@@ -0,0 +1,214 @@
+package trade
+
+import (
+	"cmp"
+	"fmt"
+	"ptcgpocket/data"
+	"ptcgpocket/userdata"
+	"slices"
+)
+
+// A card of an expansion.
+type TradeCard struct {
+	expansion *data.Expansion
+	card      *data.Card
+}
+
+func (c *TradeCard) Expansion() *data.Expansion {
+	return c.expansion
+}
+
+func (c *TradeCard) Card() *data.Card {
+	return c.card
+}
+
+func (c *TradeCard) String() string {
+	return fmt.Sprintf("%v %v %v %v", c.expansion.Name(), c.card.Number(), c.card.Rarity(), c.card.Name())
+}
+
+// Two players swapping duplicates of the same rarity, each completing a card
+// they're missing.
+type Trade struct {
+	player1 string
+	player2 string
+	// Given by player 1 to player 2
+	card1 *TradeCard
+	// Given by player 2 to player 1
+	card2 *TradeCard
+}
+
+func (t *Trade) Player1() string {
+	return t.player1
+}
+
+func (t *Trade) Player2() string {
+	return t.player2
+}
+
+// The duplicate player 1 gives.
+func (t *Trade) Card1() *TradeCard {
+	return t.card1
+}
+
+// The duplicate player 2 gives.
+func (t *Trade) Card2() *TradeCard {
+	return t.card2
+}
+
+func (t *Trade) Rarity() *data.Rarity {
+	return t.card1.card.Rarity()
+}
+
+type cardKey struct {
+	expansionIndex int
+	number         data.ExpansionCardNumber
+}
+
+type player struct {
+	name string
+	// Duplicates of tradeable cards left to trade
+	spare map[cardKey]uint8
+	// Missing tradeable cards not yet traded for
+	needs map[cardKey]bool
+}
+
+// Players' tradeable duplicates and missing cards as trades are matched.
+type matcher struct {
+	expansions []*data.Expansion
+	players    []*player
+	cards      map[cardKey]*data.Card
+}
+
+func newMatcher(expansions []*data.Expansion, profiles *userdata.Profiles) *matcher {
+	m := &matcher{expansions: expansions, cards: make(map[cardKey]*data.Card)}
+	for name, userData := range profiles.All() {
+		p := &player{name: name, spare: make(map[cardKey]uint8), needs: make(map[cardKey]bool)}
+		for i, e := range expansions {
+			eCollection := userData.Collection().GetExpansionCollection(e.Id())
+			if eCollection == nil {
+				continue
+			}
+			for c := range e.Cards() {
+				if !c.Rarity().IsTradeable() {
+					continue
+				}
+				key := cardKey{expansionIndex: i, number: c.Number()}
+				m.cards[key] = c
+				if eCollection.IsMissing(c) {
+					p.needs[key] = true
+				} else if copies := eCollection.Copies(c); copies > 1 {
+					p.spare[key] = copies - 1
+				}
+			}
+		}
+		m.players = append(m.players, p)
+	}
+	return m
+}
+
+// Cards player from can give player to.
+func (m *matcher) offers(from *player, to *player) []cardKey {
+	var keys []cardKey
+	for k, n := range from.spare {
+		if n > 0 && to.needs[k] {
+			keys = append(keys, k)
+		}
+	}
+	slices.SortFunc(keys, compareKeys)
+	return keys
+}
+
+// How many other ways the card could change hands, fewer means it's more
+// important to trade now.
+func (m *matcher) alternatives(key cardKey) int {
+	n := 0
+	for _, p := range m.players {
+		if p.spare[key] > 0 || p.needs[key] {
+			n++
+		}
+	}
+	return n
+}
+
+type candidate struct {
+	player1 *player
+	player2 *player
+	card1   cardKey
+	card2   cardKey
+	score   int
+}
+
+// The trade using the cards with the fewest alternatives, false when there
+// are no trades left.
+func (m *matcher) bestTrade() (*candidate, bool) {
+	var best *candidate
+	counted := make(map[cardKey]int)
+	alternatives := func(key cardKey) int {
+		n, nFound := counted[key]
+		if !nFound {
+			n = m.alternatives(key)
+			counted[key] = n
+		}
+		return n
+	}
+	for i, p1 := range m.players {
+		for _, p2 := range m.players[i+1:] {
+			offers1 := m.offers(p1, p2)
+			if len(offers1) == 0 {
+				continue
+			}
+			offers2 := m.offers(p2, p1)
+			for _, k1 := range offers1 {
+				for _, k2 := range offers2 {
+					if m.cards[k1].Rarity() != m.cards[k2].Rarity() {
+						continue
+					}
+					score := alternatives(k1) + alternatives(k2)
+					if best == nil || score < best.score {
+						best = &candidate{player1: p1, player2: p2, card1: k1, card2: k2, score: score}
+					}
+				}
+			}
+		}
+	}
+	return best, best != nil
+}
+
+func (m *matcher) tradeCard(key cardKey) *TradeCard {
+	return &TradeCard{expansion: m.expansions[key.expansionIndex], card: m.cards[key]}
+}
+
+func compareKeys(k1 cardKey, k2 cardKey) int {
+	return cmp.Or(cmp.Compare(k1.expansionIndex, k2.expansionIndex), cmp.Compare(k1.number, k2.number))
+}
+
+// Matches trades between the profiles, each swapping a duplicate for a
+// missing card of the same tradeable rarity, to complete as many cards across
+// the team as possible. Trades are chosen greedily, swapping the cards with
+// the fewest other players to trade with first, so it may not find the most
+// trades possible.
+func MatchTrades(expansions []*data.Expansion, profiles *userdata.Profiles) []*Trade {
+	m := newMatcher(expansions, profiles)
+	var trades []*Trade
+	for {
+		c, found := m.bestTrade()
+		if !found {
+			break
+		}
+		c.player1.spare[c.card1]--
+		c.player2.spare[c.card2]--
+		delete(c.player2.needs, c.card1)
+		delete(c.player1.needs, c.card2)
+		trades = append(trades, &Trade{
+			player1: c.player1.name,
+			player2: c.player2.name,
+			card1:   m.tradeCard(c.card1),
+			card2:   m.tradeCard(c.card2),
+		})
+	}
+
+	slices.SortStableFunc(trades, func(t1, t2 *Trade) int {
+		return cmp.Or(cmp.Compare(t1.player1, t2.player1), cmp.Compare(t1.player2, t2.player2))
+	})
+	return trades
+}
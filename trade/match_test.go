@@ -0,0 +1,56 @@
+package trade
+
+import (
+	"os"
+	"path/filepath"
+	"ptcgpocket/data"
+	"ptcgpocket/internal/testexpansion"
+	"ptcgpocket/userdata"
+	"testing"
+)
+
+func TestMatchTrades(t *testing.T) {
+	var cards []*data.Card
+	for n := range data.ExpansionCardNumber(4) {
+		cards = append(cards, data.NewCard(data.NewBaseCard("Test", 100, 0), n+1, data.RarityThreeDiamond))
+	}
+	cards = append(cards, data.NewCard(data.NewBaseCard("Test", 100, 0), 5, data.RarityThreeStar))
+	expansions := []*data.Expansion{testexpansion.New("test", cards)}
+
+	// Sam's card 1 is the only one Kim can use, so Alex should get card 2.
+	// Nobody can trade card 5 as three stars aren't tradeable.
+	raw := `{"profiles": {
+		"sam": {"collection": {"test": {"packPoints": 0, "missing": [3, 4], "duplicates": {"1": 1, "2": 1, "5": 1}}}},
+		"alex": {"collection": {"test": {"packPoints": 0, "missing": [1, 2, 5], "duplicates": {"3": 1}}}},
+		"kim": {"collection": {"test": {"packPoints": 0, "missing": [1], "duplicates": {"4": 1}}}}
+	}}`
+	dataFilepath := filepath.Join(t.TempDir(), "data.json")
+	if err := os.WriteFile(dataFilepath, []byte(raw), 0644); err != nil {
+		t.Fatal(err)
+	}
+	profiles, err := userdata.ReadProfilesFromFilepath(dataFilepath, expansions)
+	if err != nil {
+		t.Fatalf("ReadProfilesFromFilepath error = %v; want nil", err)
+	}
+
+	trades := MatchTrades(expansions, profiles)
+	if len(trades) != 2 {
+		t.Fatalf("MatchTrades = %v trades; want 2", len(trades))
+	}
+	if tr := trades[0]; tr.Player1() != "alex" || tr.Card1().Card().Number() != 3 || tr.Card2().Card().Number() != 2 {
+		t.Errorf(
+			"First trade = %v gives %v for %v; want alex gives 3 for 2",
+			tr.Player1(),
+			tr.Card1().Card().Number(),
+			tr.Card2().Card().Number(),
+		)
+	}
+	if tr := trades[1]; tr.Player1() != "kim" || tr.Card1().Card().Number() != 4 || tr.Card2().Card().Number() != 1 {
+		t.Errorf(
+			"Second trade = %v gives %v for %v; want kim gives 4 for 1",
+			tr.Player1(),
+			tr.Card1().Card().Number(),
+			tr.Card2().Card().Number(),
+		)
+	}
+}
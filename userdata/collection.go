@@ -4,77 +4,137 @@ import (
 	"fmt"
 	"iter"
 	"maps"
+	"math"
 	"ptcgpocket/data"
 	"slices"
 )
 
 type ExpansionCollection struct {
-	// Has a max of 2,500
-	packPoints   uint16
-	missingCards []*data.Card
+	expansion    *data.Expansion
+	packPoints   *PackPointPool
+	missingCards *data.CardSet
+	// Extra copies owned of a card beyond the first indexed by card number, as
+	// recorded in the user's data and added to by acquiring owned cards.
+	duplicates []uint8
+}
+
+func NewExpansionCollection(
+	expansion *data.Expansion,
+	missingCards *data.CardSet,
+	packPoints uint16,
+) *ExpansionCollection {
+	return &ExpansionCollection{
+		expansion:    expansion,
+		missingCards: missingCards.Clone(),
+		packPoints:   NewPackPointPool(packPoints),
+	}
+}
+
+func (c *ExpansionCollection) Expansion() *data.Expansion {
+	return c.expansion
 }
 
 func (c *ExpansionCollection) PackPoints() uint16 {
+	return c.packPoints.Balance()
+}
+
+// The live pack point pool shared by the expansion's boosters.
+func (c *ExpansionCollection) PackPointPool() *PackPointPool {
 	return c.packPoints
 }
 
+// The live set of missing cards, callers must not modify it.
+func (c *ExpansionCollection) Missing() *data.CardSet {
+	return c.missingCards
+}
+
+func (c *ExpansionCollection) MissingCards() []*data.Card {
+	return slices.Collect(c.expansion.CardsIn(c.missingCards))
+}
+
+func (c *ExpansionCollection) IsMissing(card *data.Card) bool {
+	return c.missingCards.Contains(card)
+}
+
+// Number of copies of the card owned, 0 when missing.
+func (c *ExpansionCollection) Copies(card *data.Card) uint8 {
+	if c.missingCards.Contains(card) {
+		return 0
+	}
+	if int(card.Number()) >= len(c.duplicates) {
+		return 1
+	}
+	return 1 + c.duplicates[card.Number()]
+}
+
+func (c *ExpansionCollection) setDuplicates(duplicates map[data.ExpansionCardNumber]uint8) {
+	c.duplicates = nil
+	for n, d := range duplicates {
+		c.growDuplicates(n)
+		c.duplicates[n] = d
+	}
+}
+
+// Extra copies by card number, only of cards with any.
+func (c *ExpansionCollection) duplicatesByNumber() map[data.ExpansionCardNumber]uint8 {
+	duplicates := make(map[data.ExpansionCardNumber]uint8)
+	for n, d := range c.duplicates {
+		if d > 0 {
+			duplicates[data.ExpansionCardNumber(n)] = d
+		}
+	}
+	return duplicates
+}
+
+func (c *ExpansionCollection) growDuplicates(number data.ExpansionCardNumber) {
+	if int(number) >= len(c.duplicates) {
+		c.duplicates = append(c.duplicates, make([]uint8, int(number)+1-len(c.duplicates))...)
+	}
+}
+
+// Redeems the card, adding a copy when it's already owned.
 func (c *ExpansionCollection) AcquireCardUsingPackPoints(
 	card *data.Card,
 ) {
-	previousCardsLength := len(c.missingCards)
-	c.missingCards = slices.DeleteFunc(c.missingCards, func(n *data.Card) bool {
-		return n == card
-	})
-	if previousCardsLength != len(c.missingCards)+1 {
-		panic("Card not missing")
+	if rErr := c.packPoints.Redeem(card.Rarity()); rErr != nil {
+		panic(rErr)
 	}
-	if c.packPoints < card.Rarity().PackPointsToObtain() {
-		panic(
-			fmt.Sprintf(
-				"Not enough pack points to obtain (%v), require (%v)",
-				c.packPoints,
-				card.Rarity().PackPointsToObtain(),
-			),
-		)
+	c.addCopy(card)
+}
+
+func (c *ExpansionCollection) AcquireCardFromTrade(card *data.Card) {
+	if !c.missingCards.Remove(card) {
+		panic("Card not missing")
 	}
-	c.packPoints -= card.Rarity().PackPointsToObtain()
 }
 
+// Adds the cards of an opened pack, returning the pack points earned.
 func (c *ExpansionCollection) AcquireCardsFromBooster(
 	added iter.Seq[*data.Card],
-) {
-	// Build a set of added cards in one pass for O(1) lookups
-	addedSet := make(map[*data.Card]struct{})
-	var numCards uint16
+) uint16 {
 	for card := range added {
-		addedSet[card] = struct{}{}
-		numCards = numCards + 1
+		c.addCopy(card)
 	}
-
-	// Delete any missing card that appears in the added set
-	c.missingCards = slices.DeleteFunc(c.missingCards, func(m *data.Card) bool {
-		_, exists := addedSet[m]
-		return exists
-	})
-
-	c.packPoints = min(c.packPoints+numCards, data.MaxPackPointsPerBooster)
-}
-
-func (c *ExpansionCollection) NumPackPoints() uint16 {
-	return c.packPoints
+	return c.packPoints.EarnFromPacks(1)
 }
 
-func (c *ExpansionCollection) UsePackPoints(amount uint16) {
-	if amount > c.packPoints {
-		panic("Trying to take out more pack points than available")
+func (c *ExpansionCollection) addCopy(card *data.Card) {
+	if c.missingCards.Remove(card) {
+		return
+	}
+	c.growDuplicates(card.Number())
+	// Saturate rather than wrap, far more copies than are ever wanted
+	if c.duplicates[card.Number()] < math.MaxUint8 {
+		c.duplicates[card.Number()]++
 	}
-	c.packPoints -= amount
 }
 
 func (c *ExpansionCollection) Clone() *ExpansionCollection {
 	return &ExpansionCollection{
-		packPoints:   c.packPoints,
-		missingCards: slices.Clone(c.missingCards),
+		expansion:    c.expansion,
+		packPoints:   c.packPoints.Clone(),
+		missingCards: c.missingCards.Clone(),
+		duplicates:   slices.Clone(c.duplicates),
 	}
 }
 
@@ -101,7 +161,7 @@ func (c *UserCollection) GetExpansionCollection(id data.ExpansionId) *ExpansionC
 
 func (c *UserCollection) FirstIncompleteExpansionId() (data.ExpansionId, error) {
 	for eId, eC := range c.expansions {
-		if len(eC.missingCards) > 0 {
+		if !eC.missingCards.IsEmpty() {
 			return eId, nil
 		}
 	}
@@ -109,6 +169,14 @@ func (c *UserCollection) FirstIncompleteExpansionId() (data.ExpansionId, error)
 }
 
 func (c *UserCollection) MissingForExpansion(expansionId data.ExpansionId) ([]*data.Card, bool) {
+	v, e := c.expansions[expansionId]
+	if v == nil {
+		return nil, e
+	}
+	return v.MissingCards(), true
+}
+
+func (c *UserCollection) MissingSetForExpansion(expansionId data.ExpansionId) (*data.CardSet, bool) {
 	v, e := c.expansions[expansionId]
 	if v == nil {
 		return nil, e
@@ -2,6 +2,7 @@ package userdata
 
 import (
 	"ptcgpocket/data"
+	"ptcgpocket/internal/testexpansion"
 	"slices"
 	"testing"
 )
@@ -27,33 +28,37 @@ func TestCreateNewCollectionWithAddedCards(t *testing.T) {
 		99,
 		data.RarityOneDiamond,
 	)
+	miCards := []*data.Card{
+		data.NewCard(
+			data.NewBaseCard("Test MI 1", 100, 0),
+			1,
+			data.RarityOneDiamond,
+		),
+		data.NewCard(
+			data.NewBaseCard("Test MI 2", 100, 0),
+			2,
+			data.RarityOneDiamond,
+		),
+		data.NewCard(
+			data.NewBaseCard("Test MI 3", 100, 0),
+			3,
+			data.RarityOneDiamond,
+		),
+	}
+	geneticApex := testexpansion.New("genetic-apex", []*data.Card{ga1, ga2, ga3, ga99})
+	mythicalIsland := testexpansion.New("mythical-island", miCards)
 	collection := NewUserCollection(
 		map[data.ExpansionId]*ExpansionCollection{
-			"genetic-apex": &ExpansionCollection{
-				missingCards: []*data.Card{
-					ga1,
-					ga2,
-					ga3,
-				},
-			},
-			"mythical-island": &ExpansionCollection{
-				missingCards: []*data.Card{
-					data.NewCard(
-						data.NewBaseCard("Test MI 1", 100, 0),
-						1,
-						data.RarityOneDiamond,
-					),
-					data.NewCard(
-						data.NewBaseCard("Test MI 2", 100, 0),
-						2,
-						data.RarityOneDiamond,
-					),
-					data.NewCard(
-						data.NewBaseCard("Test MI 3", 100, 0),
-						3,
-						data.RarityOneDiamond,
-					),
-				}},
+			"genetic-apex": NewExpansionCollection(
+				geneticApex,
+				data.NewCardSet(ga1, ga2, ga3),
+				0,
+			),
+			"mythical-island": NewExpansionCollection(
+				mythicalIsland,
+				data.NewCardSet(miCards...),
+				0,
+			),
 		},
 	)
 
@@ -68,6 +73,20 @@ func TestCreateNewCollectionWithAddedCards(t *testing.T) {
 	if newMissingForGenetic[0] != ga2 {
 		t.Errorf("New missing genetic apex incorrect contents = %v; want 2", newMissingForGenetic[0])
 	}
+	// ga99 was owned, and ga1 was opened twice
+	gaCollection := collection.GetExpansionCollection("genetic-apex")
+	if copies := gaCollection.Copies(ga99); copies != 3 {
+		t.Errorf("Copies of 99 = %v; want 3", copies)
+	}
+	if copies := gaCollection.Copies(ga1); copies != 2 {
+		t.Errorf("Copies of 1 = %v; want 2", copies)
+	}
+	gaClone := gaCollection.Clone()
+	gaClone.AcquireCardsFromBooster(slices.Values([]*data.Card{ga1}))
+	if copies := gaCollection.Copies(ga1); copies != 2 {
+		t.Errorf("Copies of 1 after acquiring in a clone = %v; want 2", copies)
+	}
+
 	newMissingForMythical, _ := collection.MissingForExpansion("mythical-island")
 	if len(newMissingForMythical) != 3 {
 		t.Errorf("New missing mythical island incorrect length = %d; want 3", len(newMissingForMythical))
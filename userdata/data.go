@@ -7,11 +7,11 @@ import (
 
 type UserData struct {
 	collection *UserCollection
-	wishlists  iter.Seq[*Wishlist]
+	wishlists  []*Wishlist
 }
 
 func NewUserData(collection *UserCollection, wishlists []*Wishlist) *UserData {
-	return &UserData{collection: collection, wishlists: slices.Values(wishlists)}
+	return &UserData{collection: collection, wishlists: slices.Clone(wishlists)}
 }
 
 func (u *UserData) Collection() *UserCollection {
@@ -19,5 +19,10 @@ func (u *UserData) Collection() *UserCollection {
 }
 
 func (u *UserData) Wishlists() iter.Seq[*Wishlist] {
-	return u.wishlists
+	return slices.Values(u.wishlists)
+}
+
+// Adds wishlists not from the data file, e.g. imported deck lists.
+func (u *UserData) AddWishlist(wishlist *Wishlist) {
+	u.wishlists = append(u.wishlists, wishlist)
 }
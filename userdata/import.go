@@ -0,0 +1,378 @@
+package userdata
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"math"
+	"ptcgpocket/data"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type ImportFormat uint8
+
+const (
+	// Set code, number and count columns, or an id and count
+	ImportCsv ImportFormat = iota
+	// An array of cards with an id or set and number, and a count, or an
+	// object of counts by card id
+	ImportJson
+)
+
+func (f ImportFormat) String() string {
+	switch f {
+	case ImportCsv:
+		return "csv"
+	case ImportJson:
+		return "json"
+	}
+	return "unknown"
+}
+
+// Format of a tracker export from its file name.
+func ImportFormatForFilename(filename string) (ImportFormat, error) {
+	lower := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(lower, ".csv"), strings.HasSuffix(lower, ".tsv"), strings.HasSuffix(lower, ".txt"):
+		return ImportCsv, nil
+	case strings.HasSuffix(lower, ".json"):
+		return ImportJson, nil
+	}
+	return 0, fmt.Errorf("unknown export format for '%v', expected .csv, .tsv, .txt or .json", filename)
+}
+
+// A row of an export that wasn't imported as given.
+type ImportIssue struct {
+	row     int
+	text    string
+	message string
+}
+
+// Line of a CSV export, or position in a JSON export, counting from 1.
+func (i *ImportIssue) Row() int {
+	return i.row
+}
+
+func (i *ImportIssue) Text() string {
+	return i.text
+}
+
+func (i *ImportIssue) Message() string {
+	return i.message
+}
+
+func (i *ImportIssue) String() string {
+	return fmt.Sprintf("row %v: %v (%v)", i.row, i.message, i.text)
+}
+
+// What an import matched, and the rows it couldn't.
+type ImportReport struct {
+	rows    int
+	matched int
+	issues  []*ImportIssue
+	// Expansions without any rows, imported as entirely missing
+	unlisted []*data.Expansion
+}
+
+// Rows of cards read, excluding any header.
+func (r *ImportReport) Rows() int {
+	return r.rows
+}
+
+// Rows matched to a card.
+func (r *ImportReport) Matched() int {
+	return r.matched
+}
+
+func (r *ImportReport) Issues() iter.Seq[*ImportIssue] {
+	return slices.Values(r.issues)
+}
+
+func (r *ImportReport) Unlisted() iter.Seq[*data.Expansion] {
+	return slices.Values(r.unlisted)
+}
+
+func (r *ImportReport) addIssue(row int, text string, format string, args ...any) {
+	r.issues = append(r.issues, &ImportIssue{row: row, text: text, message: fmt.Sprintf(format, args...)})
+}
+
+// A card row of an export before it's matched to a card.
+type importRow struct {
+	row  int
+	text string
+	// Either an id like "A1-001" or an expansion code or id and number
+	id         string
+	expansion  string
+	number     string
+	count      string
+	countFound bool
+}
+
+// Counts of each card imported so far.
+type importer struct {
+	expansions []*data.Expansion
+	counts     map[*data.Expansion]map[data.ExpansionCardNumber]uint
+	report     *ImportReport
+}
+
+func (im *importer) findExpansion(codeOrId string) (*data.Expansion, bool) {
+	for _, e := range im.expansions {
+		if strings.EqualFold(e.Code(), codeOrId) || strings.EqualFold(string(e.Id()), codeOrId) {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+func (im *importer) add(r *importRow) {
+	im.report.rows++
+
+	expansionText, numberText := r.expansion, r.number
+	if r.id != "" {
+		// Set codes can have dashes, e.g. P-A-007
+		separator := strings.LastIndexAny(r.id, "- ")
+		if separator == -1 {
+			im.report.addIssue(r.row, r.text, "expected a card id like A1-001, got '%v'", r.id)
+			return
+		}
+		expansionText, numberText = strings.TrimSpace(r.id[:separator]), strings.TrimSpace(r.id[separator+1:])
+	}
+
+	e, eFound := im.findExpansion(expansionText)
+	if !eFound {
+		im.report.addIssue(r.row, r.text, "unknown set '%v'", expansionText)
+		return
+	}
+	number, nErr := strconv.ParseUint(numberText, 10, 16)
+	if nErr != nil {
+		im.report.addIssue(r.row, r.text, "invalid card number '%v'", numberText)
+		return
+	}
+	c, cErr := e.GetCardByNumber(data.ExpansionCardNumber(number))
+	if cErr != nil {
+		im.report.addIssue(r.row, r.text, "no card %v in %v", number, e.Name())
+		return
+	}
+	count := uint64(1)
+	if r.countFound {
+		var pErr error
+		if count, pErr = strconv.ParseUint(strings.TrimSpace(r.count), 10, 32); pErr != nil {
+			im.report.addIssue(r.row, r.text, "invalid count '%v'", r.count)
+			return
+		}
+	}
+
+	im.report.matched++
+	eCounts := im.counts[e]
+	if eCounts == nil {
+		eCounts = make(map[data.ExpansionCardNumber]uint)
+		im.counts[e] = eCounts
+	}
+	if _, listed := eCounts[c.Number()]; listed {
+		im.report.addIssue(r.row, r.text, "%v %v listed again, adding the counts", e.Code(), c.Number())
+	}
+	eCounts[c.Number()] += uint(count)
+}
+
+// Cards not counted are missing, and counts over one are duplicates.
+func (im *importer) collection() *UserCollection {
+	expansionCollections := make(map[data.ExpansionId]*ExpansionCollection, len(im.expansions))
+	for _, e := range im.expansions {
+		eCounts, listed := im.counts[e]
+		if !listed {
+			im.report.unlisted = append(im.report.unlisted, e)
+		}
+		missing := data.NewCardSet()
+		duplicates := make(map[data.ExpansionCardNumber]uint8)
+		for c := range e.Cards() {
+			count := eCounts[c.Number()]
+			if count == 0 {
+				missing.Add(c)
+			} else if count > 1 {
+				duplicates[c.Number()] = uint8(min(count-1, math.MaxUint8))
+			}
+		}
+		eCollection := NewExpansionCollection(e, missing, 0)
+		eCollection.setDuplicates(duplicates)
+		expansionCollections[e.Id()] = eCollection
+	}
+	return NewUserCollection(expansionCollections)
+}
+
+// Header names of each column, lower case.
+var (
+	importIdHeaders        = []string{"id", "card id", "card_id", "cardid"}
+	importExpansionHeaders = []string{"set", "set code", "set_code", "setcode", "expansion", "code"}
+	importNumberHeaders    = []string{"number", "card number", "card_number", "no", "no.", "#"}
+	importCountHeaders     = []string{"count", "quantity", "qty", "amount", "owned", "copies"}
+)
+
+func headerIndex(header []string, names []string) int {
+	return slices.IndexFunc(header, func(h string) bool {
+		return slices.Contains(names, strings.ToLower(strings.TrimSpace(h)))
+	})
+}
+
+func importCsv(r io.Reader, im *importer) error {
+	raw, rErr := io.ReadAll(r)
+	if rErr != nil {
+		return rErr
+	}
+	reader := csv.NewReader(bytes.NewReader(raw))
+	firstLine, _, _ := bytes.Cut(raw, []byte("\n"))
+	switch {
+	case bytes.Contains(firstLine, []byte("\t")):
+		reader.Comma = '\t'
+	case bytes.Contains(firstLine, []byte(";")) && !bytes.Contains(firstLine, []byte(",")):
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	// Without a header, columns are set code, number and count
+	idColumn, expansionColumn, numberColumn, countColumn := -1, 0, 1, 2
+	field := func(record []string, column int) (string, bool) {
+		if column < 0 || column >= len(record) {
+			return "", false
+		}
+		return strings.TrimSpace(record[column]), true
+	}
+	for isFirst := true; ; isFirst = false {
+		record, cErr := reader.Read()
+		if errors.Is(cErr, io.EOF) {
+			if isFirst {
+				return errors.New("empty export")
+			}
+			return nil
+		}
+		if cErr != nil {
+			return cErr
+		}
+
+		if isFirst {
+			if i := headerIndex(record, importIdHeaders); i != -1 {
+				idColumn, expansionColumn, numberColumn = i, -1, -1
+				countColumn = headerIndex(record, importCountHeaders)
+				continue
+			}
+			e, n := headerIndex(record, importExpansionHeaders), headerIndex(record, importNumberHeaders)
+			if e != -1 && n != -1 {
+				expansionColumn, numberColumn = e, n
+				countColumn = headerIndex(record, importCountHeaders)
+				continue
+			}
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := &importRow{row: line, text: strings.Join(record, string(reader.Comma))}
+		row.id, _ = field(record, idColumn)
+		row.expansion, _ = field(record, expansionColumn)
+		row.number, _ = field(record, numberColumn)
+		row.count, row.countFound = field(record, countColumn)
+		if row.id == "" && (row.expansion == "" || row.number == "") {
+			im.report.rows++
+			im.report.addIssue(row.row, row.text, "expected a set code and number or a card id")
+			continue
+		}
+		im.add(row)
+	}
+}
+
+// A card of a JSON export, numbers and counts can be strings or numbers.
+type serialisedImportCard struct {
+	Id        string      `json:"id"`
+	CardId    string      `json:"cardId"`
+	Set       string      `json:"set"`
+	SetCode   string      `json:"setCode"`
+	Expansion string      `json:"expansion"`
+	Number    json.Number `json:"number"`
+	Count     json.Number `json:"count"`
+	Quantity  json.Number `json:"quantity"`
+	Owned     json.Number `json:"owned"`
+}
+
+func importJson(r io.Reader, im *importer) error {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	var raw json.RawMessage
+	if dErr := decoder.Decode(&raw); dErr != nil {
+		return dErr
+	}
+
+	var countsById map[string]json.Number
+	if json.Unmarshal(raw, &countsById) == nil {
+		for i, id := range slices.Sorted(maps.Keys(countsById)) {
+			count := countsById[id].String()
+			im.add(&importRow{
+				row:        i + 1,
+				text:       fmt.Sprintf("%q: %v", id, count),
+				id:         id,
+				count:      count,
+				countFound: true,
+			})
+		}
+		return nil
+	}
+
+	var rawCards []json.RawMessage
+	if uErr := json.Unmarshal(raw, &rawCards); uErr != nil {
+		return errors.New("expected an array of cards or an object of counts by card id")
+	}
+	for i, rawCard := range rawCards {
+		row := &importRow{row: i + 1, text: string(rawCard)}
+		var c serialisedImportCard
+		if uErr := json.Unmarshal(rawCard, &c); uErr != nil {
+			im.report.rows++
+			im.report.addIssue(row.row, row.text, "%v", uErr)
+			continue
+		}
+		row.id = cmp.Or(c.Id, c.CardId)
+		row.expansion = cmp.Or(c.SetCode, c.Set, c.Expansion)
+		row.number = c.Number.String()
+		row.count = cmp.Or(c.Count.String(), c.Quantity.String(), c.Owned.String())
+		row.countFound = row.count != ""
+		if row.id == "" && (row.expansion == "" || row.number == "") {
+			im.report.rows++
+			im.report.addIssue(row.row, row.text, "expected a set code and number or a card id")
+			continue
+		}
+		im.add(row)
+	}
+	return nil
+}
+
+// Imports a collection tracker export. Cards of the expansions without a
+// count are missing, including every card of expansions the export doesn't
+// list. Pack points aren't exported so start at 0.
+func ImportCollection(
+	r io.Reader,
+	format ImportFormat,
+	expansions []*data.Expansion,
+) (*UserCollection, *ImportReport, error) {
+	im := &importer{
+		expansions: expansions,
+		counts:     make(map[*data.Expansion]map[data.ExpansionCardNumber]uint),
+		report:     &ImportReport{},
+	}
+	var iErr error
+	switch format {
+	case ImportCsv:
+		iErr = importCsv(r, im)
+	case ImportJson:
+		iErr = importJson(r, im)
+	default:
+		iErr = fmt.Errorf("unknown import format %v", format)
+	}
+	if iErr != nil {
+		return nil, nil, fmt.Errorf("importing %v: %w", format, iErr)
+	}
+	return im.collection(), im.report, nil
+}
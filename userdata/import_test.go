@@ -0,0 +1,124 @@
+package userdata
+
+import (
+	"bytes"
+	"encoding/json"
+	"ptcgpocket/data"
+	"ptcgpocket/internal/testexpansion"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newImportTestExpansions() []*data.Expansion {
+	var expansions []*data.Expansion
+	for _, id := range []data.ExpansionId{"A1", "A2"} {
+		var cards []*data.Card
+		for n := range data.ExpansionCardNumber(3) {
+			cards = append(cards, data.NewCard(data.NewBaseCard("Test", 100, 0), n+1, data.RarityOneDiamond))
+		}
+		expansions = append(expansions, testexpansion.New(id, cards))
+	}
+	return expansions
+}
+
+func TestImportCsv(t *testing.T) {
+	expansions := newImportTestExpansions()
+	export := "Quantity,Number,Set Code\n3,1,a1\n1,2,A1\n1,4,A1\n1,1,B9\n"
+
+	collection, report, err := ImportCollection(strings.NewReader(export), ImportCsv, expansions)
+	if err != nil {
+		t.Fatalf("ImportCollection error = %v; want nil", err)
+	}
+	if report.Rows() != 4 || report.Matched() != 2 {
+		t.Errorf("Rows, Matched = %v, %v; want 4, 2", report.Rows(), report.Matched())
+	}
+	var issueRows []int
+	for i := range report.Issues() {
+		issueRows = append(issueRows, i.Row())
+	}
+	if !slices.Equal(issueRows, []int{4, 5}) {
+		t.Errorf("Issues rows = %v; want [4 5]", issueRows)
+	}
+	if unlisted := slices.Collect(report.Unlisted()); len(unlisted) != 1 || unlisted[0] != expansions[1] {
+		t.Errorf("Unlisted = %v; want [A2]", unlisted)
+	}
+
+	a1 := collection.GetExpansionCollection("A1")
+	for n, want := range []uint8{3, 1, 0} {
+		c, _ := expansions[0].GetCardByNumber(data.ExpansionCardNumber(n + 1))
+		if copies := a1.Copies(c); copies != want {
+			t.Errorf("A1 card %v Copies = %v; want %v", n+1, copies, want)
+		}
+	}
+	if missing := collection.GetExpansionCollection("A2").Missing().Len(); missing != 3 {
+		t.Errorf("A2 missing = %v; want 3", missing)
+	}
+}
+
+func TestImportCsvWithoutHeader(t *testing.T) {
+	expansions := newImportTestExpansions()
+	export := "A1;1;1\nA1;3;0\n"
+
+	collection, report, err := ImportCollection(strings.NewReader(export), ImportCsv, expansions)
+	if err != nil {
+		t.Fatalf("ImportCollection error = %v; want nil", err)
+	}
+	if report.Matched() != 2 {
+		t.Errorf("Matched = %v; want 2", report.Matched())
+	}
+	if missing := slices.Collect(collection.GetExpansionCollection("A1").Missing().Numbers()); !slices.Equal(missing, []data.ExpansionCardNumber{2, 3}) {
+		t.Errorf("A1 missing = %v; want [2 3]", missing)
+	}
+}
+
+func TestImportJson(t *testing.T) {
+	expansions := newImportTestExpansions()
+	for _, export := range []string{
+		`[{"id": "A1-001", "count": 2}, {"setCode": "A2", "number": "3", "quantity": 1}, {"id": "A1-x"}]`,
+		`{"A1-001": 2, "A2-003": 1, "A1-x": 1}`,
+	} {
+		collection, report, err := ImportCollection(strings.NewReader(export), ImportJson, expansions)
+		if err != nil {
+			t.Fatalf("ImportCollection(%v) error = %v; want nil", export, err)
+		}
+		if report.Rows() != 3 || report.Matched() != 2 {
+			t.Errorf("ImportCollection(%v) Rows, Matched = %v, %v; want 3, 2", export, report.Rows(), report.Matched())
+		}
+		c, _ := expansions[0].GetCardByNumber(1)
+		if copies := collection.GetExpansionCollection("A1").Copies(c); copies != 2 {
+			t.Errorf("ImportCollection(%v) A1 card 1 Copies = %v; want 2", export, copies)
+		}
+		if missing := collection.GetExpansionCollection("A2").Missing().Len(); missing != 2 {
+			t.Errorf("ImportCollection(%v) A2 missing = %v; want 2", export, missing)
+		}
+	}
+}
+
+func TestWriteCollection(t *testing.T) {
+	expansions := newImportTestExpansions()
+	collection, _, err := ImportCollection(strings.NewReader("A1,1,2\nA2,2,1\n"), ImportCsv, expansions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var written bytes.Buffer
+	if err := WriteCollection(&written, collection); err != nil {
+		t.Fatalf("WriteCollection error = %v; want nil", err)
+	}
+	var serialised serialisedUserData
+	if err := json.Unmarshal(written.Bytes(), &serialised); err != nil {
+		t.Fatal(err)
+	}
+	userData, err := readUserData(&serialised, expansions)
+	if err != nil {
+		t.Fatalf("reading written collection error = %v; want nil", err)
+	}
+	c, _ := expansions[0].GetCardByNumber(1)
+	if copies := userData.Collection().GetExpansionCollection("A1").Copies(c); copies != 2 {
+		t.Errorf("A1 card 1 Copies = %v; want 2", copies)
+	}
+	if missing := userData.Collection().GetExpansionCollection("A2").Missing().Len(); missing != 2 {
+		t.Errorf("A2 missing = %v; want 2", missing)
+	}
+}
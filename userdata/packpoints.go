@@ -0,0 +1,70 @@
+package userdata
+
+import (
+	"fmt"
+	"ptcgpocket/data"
+)
+
+// Pack points earned for each pack opened, whatever its cards.
+const PackPointsPerPack uint16 = 5
+
+// An expansion's pack points, earned by opening any of its boosters and spent
+// on its cards. Points stop accruing at data.MaxPackPointsPerBooster.
+type PackPointPool struct {
+	balance uint16
+}
+
+func NewPackPointPool(balance uint16) *PackPointPool {
+	return &PackPointPool{balance: min(balance, data.MaxPackPointsPerBooster)}
+}
+
+func (p *PackPointPool) Balance() uint16 {
+	return p.balance
+}
+
+// Whether opening more packs would earn nothing.
+func (p *PackPointPool) IsFull() bool {
+	return p.balance >= data.MaxPackPointsPerBooster
+}
+
+func (p *PackPointPool) CanRedeem(rarity *data.Rarity) bool {
+	return rarity.PackPointsToObtain() <= p.balance
+}
+
+// Adds the points for opening the packs, returning how many were earned
+// before reaching the cap.
+func (p *PackPointPool) EarnFromPacks(packs uint16) uint16 {
+	earned := min(uint32(packs)*uint32(PackPointsPerPack), uint32(data.MaxPackPointsPerBooster-p.balance))
+	p.balance += uint16(earned)
+	return uint16(earned)
+}
+
+func (p *PackPointPool) Redeem(rarity *data.Rarity) error {
+	if !p.CanRedeem(rarity) {
+		return fmt.Errorf(
+			"not enough pack points to obtain %v (%v), require (%v)",
+			rarity,
+			p.balance,
+			rarity.PackPointsToObtain(),
+		)
+	}
+	p.balance -= rarity.PackPointsToObtain()
+	return nil
+}
+
+// Packs to open before the pool holds the points, 0 when it already does.
+// Points over the cap can never be held.
+func (p *PackPointPool) PacksToEarn(points uint) (uint, error) {
+	if points > uint(data.MaxPackPointsPerBooster) {
+		return 0, fmt.Errorf("%v pack points is over the %v cap", points, data.MaxPackPointsPerBooster)
+	}
+	if points <= uint(p.balance) {
+		return 0, nil
+	}
+	perPack := uint(PackPointsPerPack)
+	return (points - uint(p.balance) + perPack - 1) / perPack, nil
+}
+
+func (p *PackPointPool) Clone() *PackPointPool {
+	return &PackPointPool{balance: p.balance}
+}
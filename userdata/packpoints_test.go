@@ -0,0 +1,45 @@
+package userdata
+
+import (
+	"ptcgpocket/data"
+	"ptcgpocket/internal/testexpansion"
+	"slices"
+	"testing"
+)
+
+func TestPackPointPool(t *testing.T) {
+	pool := NewPackPointPool(data.MaxPackPointsPerBooster - 7)
+	if earned := pool.EarnFromPacks(2); earned != 7 || !pool.IsFull() {
+		t.Errorf("EarnFromPacks near the cap = %v, full %v; want 7, true", earned, pool.IsFull())
+	}
+	if earned := pool.EarnFromPacks(1); earned != 0 {
+		t.Errorf("EarnFromPacks when full = %v; want 0", earned)
+	}
+
+	if err := pool.Redeem(data.RarityCrown); err != nil || pool.Balance() != 0 {
+		t.Errorf("Redeem crown = %v, balance %v; want nil, 0", err, pool.Balance())
+	}
+	if err := pool.Redeem(data.RarityOneDiamond); err == nil {
+		t.Errorf("Redeem without points error = nil; want error")
+	}
+
+	pool.EarnFromPacks(1)
+	if packs, err := pool.PacksToEarn(uint(data.RarityTwoDiamond.PackPointsToObtain())); err != nil || packs != 13 {
+		t.Errorf("PacksToEarn(70) from 5 = %v, %v; want 13", packs, err)
+	}
+	if _, err := pool.PacksToEarn(2 * uint(data.MaxPackPointsPerBooster)); err == nil {
+		t.Errorf("PacksToEarn over the cap error = nil; want error")
+	}
+}
+
+func TestAcquireCardsFromBoosterEarnsPerPack(t *testing.T) {
+	c1 := data.NewCard(data.NewBaseCard("Test 1", 100, 0), 1, data.RarityOneDiamond)
+	c2 := data.NewCard(data.NewBaseCard("Test 2", 100, 0), 2, data.RarityOneDiamond)
+	collection := NewExpansionCollection(testexpansion.New("genetic-apex", []*data.Card{c1, c2}), data.NewCardSet(c1, c2), 0)
+
+	// Points are earned per pack, not per card
+	earned := collection.AcquireCardsFromBooster(slices.Values([]*data.Card{c1, c1, c1, c1, c1, c1}))
+	if earned != PackPointsPerPack || collection.PackPoints() != PackPointsPerPack {
+		t.Errorf("Pack points after a 6 card pack = %v, %v; want %v", earned, collection.PackPoints(), PackPointsPerPack)
+	}
+}
@@ -0,0 +1,108 @@
+package userdata
+
+import (
+	"fmt"
+	"iter"
+	"ptcgpocket/data"
+	"slices"
+	"strings"
+)
+
+// Name of the only profile of a data file without profiles.
+const DefaultProfileName = "default"
+
+// Players' user data by name.
+type Profiles struct {
+	names  []string
+	byName map[string]*UserData
+}
+
+func newProfiles() *Profiles {
+	return &Profiles{byName: make(map[string]*UserData)}
+}
+
+func NewProfiles(byName map[string]*UserData) *Profiles {
+	profiles := newProfiles()
+	for name, userData := range byName {
+		profiles.add(name, userData)
+	}
+	return profiles
+}
+
+func (p *Profiles) add(name string, userData *UserData) {
+	p.byName[name] = userData
+	index, _ := slices.BinarySearch(p.names, name)
+	p.names = slices.Insert(p.names, index, name)
+}
+
+func (p *Profiles) Len() int {
+	return len(p.names)
+}
+
+// Profile names in alphabetical order.
+func (p *Profiles) Names() iter.Seq[string] {
+	return slices.Values(p.names)
+}
+
+// Profiles in name order.
+func (p *Profiles) All() iter.Seq2[string, *UserData] {
+	return func(yield func(string, *UserData) bool) {
+		for _, n := range p.names {
+			if !yield(n, p.byName[n]) {
+				return
+			}
+		}
+	}
+}
+
+// The named profile, or the only profile when name is empty.
+func (p *Profiles) Get(name string) (*UserData, error) {
+	if name == "" {
+		if len(p.names) != 1 {
+			return nil, fmt.Errorf("choose a profile of %v", strings.Join(p.names, ", "))
+		}
+		return p.byName[p.names[0]], nil
+	}
+	userData, found := p.byName[name]
+	if !found {
+		return nil, fmt.Errorf("no profile %v, expected one of %v", name, strings.Join(p.names, ", "))
+	}
+	return userData, nil
+}
+
+// A profile's number of missing cards of a rarity in an expansion.
+type ProfileMissing struct {
+	name    string
+	missing int
+}
+
+func (m *ProfileMissing) Name() string {
+	return m.name
+}
+
+func (m *ProfileMissing) Missing() int {
+	return m.missing
+}
+
+// Profiles by most missing cards of the rarity in the expansion, then by
+// name. Profiles without a collection for the expansion are left out.
+func (p *Profiles) RankByMissing(expansion *data.Expansion, rarity *data.Rarity) []*ProfileMissing {
+	var ranked []*ProfileMissing
+	for name, userData := range p.All() {
+		missing, mFound := userData.Collection().MissingSetForExpansion(expansion.Id())
+		if !mFound {
+			continue
+		}
+		count := 0
+		for c := range expansion.CardsIn(missing) {
+			if c.Rarity() == rarity {
+				count++
+			}
+		}
+		ranked = append(ranked, &ProfileMissing{name: name, missing: count})
+	}
+	slices.SortStableFunc(ranked, func(m1, m2 *ProfileMissing) int {
+		return m2.missing - m1.missing
+	})
+	return ranked
+}
@@ -0,0 +1,67 @@
+package userdata
+
+import (
+	"os"
+	"path/filepath"
+	"ptcgpocket/data"
+	"ptcgpocket/internal/testexpansion"
+	"slices"
+	"testing"
+)
+
+func TestReadProfiles(t *testing.T) {
+	var cards []*data.Card
+	for n := range data.ExpansionCardNumber(3) {
+		cards = append(cards, data.NewCard(data.NewBaseCard("Test", 100, 0), n+1, data.RarityOneDiamond))
+	}
+	expansions := []*data.Expansion{testexpansion.New("test", cards)}
+
+	dataFilepath := filepath.Join(t.TempDir(), "data.json")
+	raw := `{"profiles": {
+		"sam": {"collection": {"test": {"packPoints": 0, "missing": [1]}}},
+		"alex": {"collection": {"test": {"packPoints": 0, "missing": [1, 2, 3]}}}
+	}}`
+	if err := os.WriteFile(dataFilepath, []byte(raw), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	profiles, err := ReadProfilesFromFilepath(dataFilepath, expansions)
+	if err != nil {
+		t.Fatalf("ReadProfilesFromFilepath error = %v; want nil", err)
+	}
+	if names := slices.Collect(profiles.Names()); !slices.Equal(names, []string{"alex", "sam"}) {
+		t.Errorf("Names = %v; want [alex sam]", names)
+	}
+	if _, err := profiles.Get(""); err == nil {
+		t.Errorf("Get without a name error = nil; want error choosing between profiles")
+	}
+	if _, err := ReadFromFilepath(dataFilepath, expansions); err == nil {
+		t.Errorf("ReadFromFilepath of profiles error = nil; want error")
+	}
+
+	ranked := profiles.RankByMissing(expansions[0], data.RarityOneDiamond)
+	if len(ranked) != 2 || ranked[0].Name() != "alex" || ranked[0].Missing() != 3 || ranked[1].Missing() != 1 {
+		t.Errorf("RankByMissing = %v; want alex 3 then sam 1", ranked)
+	}
+}
+
+func TestReadProfilesDirectory(t *testing.T) {
+	expansions := []*data.Expansion{testexpansion.New("test", []*data.Card{
+		data.NewCard(data.NewBaseCard("Test", 100, 0), 1, data.RarityOneDiamond),
+	})}
+	dir := t.TempDir()
+	for _, name := range []string{"sam.json", "notes.txt"} {
+		raw := `{"collection": {"test": {"packPoints": 0, "missing": [1]}}}`
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(raw), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	profiles, err := ReadProfilesFromFilepath(dir, expansions)
+	if err != nil {
+		t.Fatalf("ReadProfilesFromFilepath error = %v; want nil", err)
+	}
+	if _, err := profiles.Get("sam"); err != nil || profiles.Len() != 1 {
+		t.Errorf("Get(sam) error = %v with %v profiles; want nil with 1", err, profiles.Len())
+	}
+}
@@ -4,32 +4,168 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"ptcgpocket/data"
 	"slices"
+	"strings"
 )
 
 type serialisedExpansionCollection struct {
 	Missing    []data.ExpansionCardNumber `json:"missing"`
 	PackPoints uint16                     `json:"packPoints"`
+	// Extra copies owned of a card beyond the first
+	Duplicates map[data.ExpansionCardNumber]uint8 `json:"duplicates,omitempty"`
+}
+
+// A wishlist card, either just its number with normal priority or an object
+// with a priority or explicit weight, and the copies to own.
+type serialisedWishlistCard struct {
+	Number   data.ExpansionCardNumber `json:"number"`
+	Priority Priority                 `json:"priority"`
+	Weight   *float64                 `json:"weight"`
+	Copies   *uint8                   `json:"copies"`
+}
+
+func (c *serialisedWishlistCard) UnmarshalJSON(raw []byte) error {
+	var number data.ExpansionCardNumber
+	if json.Unmarshal(raw, &number) == nil {
+		*c = serialisedWishlistCard{Number: number}
+		return nil
+	}
+
+	type plain serialisedWishlistCard
+	var p plain
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return err
+	}
+	*c = serialisedWishlistCard(p)
+	return nil
+}
+
+func (c *serialisedWishlistCard) weight() (float64, error) {
+	if c.Weight != nil {
+		if *c.Weight <= 0 {
+			return 0, fmt.Errorf("card %v has weight %v, weights must be positive", c.Number, *c.Weight)
+		}
+		if c.Priority != "" {
+			return 0, fmt.Errorf("card %v has both a priority and a weight", c.Number)
+		}
+		return *c.Weight, nil
+	}
+	if c.Priority == "" {
+		return PriorityNormal.Weight(), nil
+	}
+	p, pErr := ParsePriority(string(c.Priority))
+	if pErr != nil {
+		return 0, fmt.Errorf("card %v: %w", c.Number, pErr)
+	}
+	return p.Weight(), nil
+}
+
+func (c *serialisedWishlistCard) copies() (uint8, error) {
+	if c.Copies == nil {
+		return 1, nil
+	}
+	if *c.Copies == 0 {
+		return 0, fmt.Errorf("card %v wants 0 copies", c.Number)
+	}
+	return *c.Copies, nil
 }
 
 type serialisedUserData struct {
-	Collection map[data.ExpansionId]*serialisedExpansionCollection        `json:"collection"`
-	Wishlists  map[string]map[data.ExpansionId][]data.ExpansionCardNumber `json:"wishlists"`
+	Collection map[data.ExpansionId]*serialisedExpansionCollection       `json:"collection"`
+	Wishlists  map[string]map[data.ExpansionId][]*serialisedWishlistCard `json:"wishlists,omitempty"`
 }
 
-func ReadFromFilepath(filepath string, expansions []*data.Expansion) (*UserData, error) {
-	raw, err := os.ReadFile(filepath)
-	if err != nil {
-		return nil, err
+// A data file, either a single collection and wishlists or several named
+// profiles.
+type serialisedDataFile struct {
+	serialisedUserData
+	Profiles map[string]*serialisedUserData `json:"profiles"`
+}
+
+// Reads the profiles from a data file, or a directory with a JSON file for
+// each profile named after the file. A data file without profiles is a single
+// profile named DefaultProfileName.
+func ReadProfilesFromFilepath(path string, expansions []*data.Expansion) (*Profiles, error) {
+	info, sErr := os.Stat(path)
+	if sErr != nil {
+		return nil, sErr
+	}
+
+	profiles := newProfiles()
+	if info.IsDir() {
+		entries, rErr := os.ReadDir(path)
+		if rErr != nil {
+			return nil, rErr
+		}
+		for _, entry := range entries {
+			name, isJson := strings.CutSuffix(entry.Name(), ".json")
+			if entry.IsDir() || !isJson {
+				continue
+			}
+			var serialised serialisedUserData
+			if rErr := readJsonFile(filepath.Join(path, entry.Name()), &serialised); rErr != nil {
+				return nil, rErr
+			}
+			userData, uErr := readUserData(&serialised, expansions)
+			if uErr != nil {
+				return nil, fmt.Errorf("profile %v: %w", name, uErr)
+			}
+			profiles.add(name, userData)
+		}
+		if profiles.Len() == 0 {
+			return nil, fmt.Errorf("no profiles in %v", path)
+		}
+		return profiles, nil
+	}
+
+	var serialised serialisedDataFile
+	if rErr := readJsonFile(path, &serialised); rErr != nil {
+		return nil, rErr
+	}
+	if len(serialised.Profiles) == 0 {
+		userData, uErr := readUserData(&serialised.serialisedUserData, expansions)
+		if uErr != nil {
+			return nil, uErr
+		}
+		profiles.add(DefaultProfileName, userData)
+		return profiles, nil
+	}
+	if serialised.Collection != nil || serialised.Wishlists != nil {
+		return nil, fmt.Errorf("%v has both profiles and a top level collection or wishlists", path)
 	}
+	for name, s := range serialised.Profiles {
+		userData, uErr := readUserData(s, expansions)
+		if uErr != nil {
+			return nil, fmt.Errorf("profile %v: %w", name, uErr)
+		}
+		profiles.add(name, userData)
+	}
+	return profiles, nil
+}
 
-	var serialisedUserData serialisedUserData
-	uErr := json.Unmarshal(raw, &serialisedUserData)
-	if uErr != nil {
-		return nil, uErr
+// Reads a data file or directory with a single profile.
+func ReadFromFilepath(path string, expansions []*data.Expansion) (*UserData, error) {
+	profiles, pErr := ReadProfilesFromFilepath(path, expansions)
+	if pErr != nil {
+		return nil, pErr
 	}
+	return profiles.Get("")
+}
 
+func readJsonFile(path string, v any) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if uErr := json.Unmarshal(raw, v); uErr != nil {
+		return fmt.Errorf("%v: %w", path, uErr)
+	}
+	return nil
+}
+
+func readUserData(serialisedUserData *serialisedUserData, expansions []*data.Expansion) (*UserData, error) {
 	expansionCollections := make(map[data.ExpansionId]*ExpansionCollection, len(serialisedUserData.Collection))
 	for i, s := range serialisedUserData.Collection {
 		eIndex := slices.IndexFunc(expansions, func(e *data.Expansion) bool {
@@ -40,18 +176,33 @@ func ReadFromFilepath(filepath string, expansions []*data.Expansion) (*UserData,
 		}
 
 		e := expansions[eIndex]
-		missingCards := make([]*data.Card, len(s.Missing))
-		for i, m := range s.Missing {
+		missingCards := data.NewCardSet()
+		for _, m := range s.Missing {
 			c, cErr := e.GetCardByNumber(m)
 			if cErr != nil {
-				panic(cErr)
+				return nil, fmt.Errorf("%v missing card %v isn't in the catalogue", i, m)
 			}
-			missingCards[i] = c
+			missingCards.Add(c)
+		}
+		for n := range s.Duplicates {
+			c, cErr := e.GetCardByNumber(n)
+			if cErr != nil {
+				return nil, fmt.Errorf("%v duplicate card %v isn't in the catalogue", i, n)
+			}
+			if missingCards.Contains(c) {
+				return nil, fmt.Errorf("%v card %v has duplicates but is missing", i, n)
+			}
+		}
+		if s.PackPoints > data.MaxPackPointsPerBooster {
+			return nil, fmt.Errorf("%v has %v pack points, over the %v cap", i, s.PackPoints, data.MaxPackPointsPerBooster)
 		}
-		expansionCollections[i] = &ExpansionCollection{
+		eCollection := &ExpansionCollection{
+			expansion:    e,
 			missingCards: missingCards,
-			packPoints:   s.PackPoints,
+			packPoints:   NewPackPointPool(s.PackPoints),
 		}
+		eCollection.setDuplicates(s.Duplicates)
+		expansionCollections[i] = eCollection
 	}
 
 	wishlists := make([]*Wishlist, len(serialisedUserData.Wishlists))
@@ -68,22 +219,31 @@ func ReadFromFilepath(filepath string, expansions []*data.Expansion) (*UserData,
 
 			e := expansions[eIndex]
 			cards := make([]*data.Card, len(m))
-			for i, cardNumber := range m {
-				c, cErr := e.GetCardByNumber(cardNumber)
+			weights := make(data.CardWeights, len(m))
+			copies := make(map[data.ExpansionCardNumber]uint8, len(m))
+			for i, wishlistCard := range m {
+				c, cErr := e.GetCardByNumber(wishlistCard.Number)
 				if cErr != nil {
-					panic(cErr)
+					return nil, fmt.Errorf("wishlist %v %v card %v isn't in the catalogue", n, eId, wishlistCard.Number)
 				}
 				cards[i] = c
-			}
 
-			expansionWishlists[e.Id()] = &ExpansionWishlist{
-				cards: cards,
+				w, wErr := wishlistCard.weight()
+				if wErr != nil {
+					return nil, fmt.Errorf("wishlist %v %v: %w", n, eId, wErr)
+				}
+				weights[c.Number()] = w
+
+				wanted, wantedErr := wishlistCard.copies()
+				if wantedErr != nil {
+					return nil, fmt.Errorf("wishlist %v %v: %w", n, eId, wantedErr)
+				}
+				copies[c.Number()] = wanted
 			}
+
+			expansionWishlists[e.Id()] = NewExpansionWishlist(cards, weights, copies)
 		}
-		wishlists[i] = &Wishlist{
-			name:       n,
-			expansions: expansionWishlists,
-		}
+		wishlists[i] = NewWishlist(n, expansionWishlists)
 		i++
 	}
 
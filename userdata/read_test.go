@@ -0,0 +1,25 @@
+package userdata
+
+import (
+	"os"
+	"path/filepath"
+	"ptcgpocket/data"
+	"ptcgpocket/internal/testexpansion"
+	"strings"
+	"testing"
+)
+
+func TestReadUnknownCard(t *testing.T) {
+	expansions := []*data.Expansion{testexpansion.New("test", []*data.Card{
+		data.NewCard(data.NewBaseCard("Test", 100, 0), 1, data.RarityOneDiamond),
+	})}
+	dataFilepath := filepath.Join(t.TempDir(), "data.json")
+	if err := os.WriteFile(dataFilepath, []byte(`{"collection": {"test": {"packPoints": 0, "missing": [1, 2]}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ReadFromFilepath(dataFilepath, expansions)
+	if err == nil || !strings.Contains(err.Error(), "card 2") {
+		t.Errorf("ReadFromFilepath error = %v; want error naming card 2", err)
+	}
+}
@@ -1,9 +1,47 @@
 package userdata
 
-import "ptcgpocket/data"
+import (
+	"fmt"
+	"maps"
+	"ptcgpocket/data"
+)
+
+type Priority string
+
+const (
+	PriorityMustHave   Priority = "must-have"
+	PriorityNormal     Priority = "normal"
+	PriorityNiceToHave Priority = "nice-to-have"
+)
+
+var priorityWeights = map[Priority]float64{
+	PriorityMustHave:   4,
+	PriorityNormal:     2,
+	PriorityNiceToHave: 1,
+}
+
+// Weight of a missing card that isn't on a wishlist, when scoring wishlist
+// cards and the rest of the collection together.
+const CollectionCardWeight = 1.0
+
+func ParsePriority(value string) (Priority, error) {
+	p := Priority(value)
+	if _, pFound := priorityWeights[p]; !pFound {
+		return "", fmt.Errorf("unknown priority '%v'", value)
+	}
+	return p, nil
+}
+
+func (p Priority) Weight() float64 {
+	return priorityWeights[p]
+}
 
 type ExpansionWishlist struct {
-	cards []*data.Card
+	cards   []*data.Card
+	cardSet *data.CardSet
+	weights data.CardWeights
+	// Copies of a card to own, e.g. two for a deck, only when more than one
+	copies map[data.ExpansionCardNumber]uint8
 }
 
 type Wishlist struct {
@@ -11,6 +49,40 @@ type Wishlist struct {
 	expansions map[data.ExpansionId]*ExpansionWishlist
 }
 
+// Cards without a weight are given PriorityNormal's, and without copies are
+// wanted once.
+func NewExpansionWishlist(
+	cards []*data.Card,
+	weights data.CardWeights,
+	copies map[data.ExpansionCardNumber]uint8,
+) *ExpansionWishlist {
+	allWeights := make(data.CardWeights, len(cards))
+	for _, c := range cards {
+		w, wFound := weights[c.Number()]
+		if !wFound {
+			w = PriorityNormal.Weight()
+		}
+		allWeights[c.Number()] = w
+	}
+	return &ExpansionWishlist{
+		cards:   cards,
+		cardSet: data.NewCardSet(cards...),
+		weights: allWeights,
+		copies:  maps.Clone(copies),
+	}
+}
+
+func (w *ExpansionWishlist) copiesWanted(card *data.Card) uint8 {
+	if copies, cFound := w.copies[card.Number()]; cFound {
+		return copies
+	}
+	return 1
+}
+
+func NewWishlist(name string, expansions map[data.ExpansionId]*ExpansionWishlist) *Wishlist {
+	return &Wishlist{name: name, expansions: expansions}
+}
+
 func (w *Wishlist) Name() string {
 	return w.name
 }
@@ -22,3 +94,97 @@ func (w *Wishlist) CardsForExpansion(expansionId data.ExpansionId) ([]*data.Card
 	}
 	return eW.cards, true
 }
+
+// The expansion's wishlist cards as a set, which must not be modified.
+func (w *Wishlist) CardSetForExpansion(expansionId data.ExpansionId) (*data.CardSet, bool) {
+	eW, eWFound := w.expansions[expansionId]
+	if !eWFound {
+		return nil, false
+	}
+	return eW.cardSet, true
+}
+
+// Copies of a wishlist card to own, 0 when it isn't on the wishlist.
+func (w *Wishlist) CopiesWanted(expansionId data.ExpansionId, card *data.Card) uint8 {
+	eW, eWFound := w.expansions[expansionId]
+	if !eWFound || !eW.cardSet.Contains(card) {
+		return 0
+	}
+	return eW.copiesWanted(card)
+}
+
+// Whether the collection owns every copy wanted of the expansion's wishlist
+// cards, which expansions without any do from the start.
+func (w *Wishlist) IsComplete(eCollection *ExpansionCollection) bool {
+	eW, eWFound := w.expansions[eCollection.Expansion().Id()]
+	if !eWFound {
+		return true
+	}
+	for _, c := range eW.cards {
+		if eCollection.Copies(c) < eW.copiesWanted(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// The expansion's wishlist cards the collection owns fewer copies of than
+// wanted.
+func (w *Wishlist) Wanted(eCollection *ExpansionCollection) *data.CardSet {
+	wanted := data.NewCardSet()
+	eW, eWFound := w.expansions[eCollection.Expansion().Id()]
+	if !eWFound {
+		return wanted
+	}
+	for _, c := range eW.cards {
+		if eCollection.Copies(c) < eW.copiesWanted(c) {
+			wanted.Add(c)
+		}
+	}
+	return wanted
+}
+
+// Weight of each wishlist card in the expansion.
+func (w *Wishlist) WeightsForExpansion(expansionId data.ExpansionId) (data.CardWeights, bool) {
+	eW, eWFound := w.expansions[expansionId]
+	if !eWFound {
+		return nil, false
+	}
+	return eW.weights, true
+}
+
+// Weights for scoring the wishlist together with the rest of the missing
+// collection, wishlist cards keep their own weight.
+func (w *Wishlist) WeightsWithCollection(
+	expansionId data.ExpansionId,
+	missing *data.CardSet,
+) data.CardWeights {
+	weights := make(data.CardWeights)
+	if missing != nil {
+		for n := range missing.Numbers() {
+			weights[n] = CollectionCardWeight
+		}
+	}
+	if eW, eWFound := w.expansions[expansionId]; eWFound {
+		for n, wt := range eW.weights {
+			weights[n] = wt
+		}
+	}
+	return weights
+}
+
+// Combines the weights of several wishlists, keeping the highest weight of
+// each card.
+func MergeWishlistWeights(
+	wishlists []*Wishlist,
+	expansionId data.ExpansionId,
+	missing *data.CardSet,
+) data.CardWeights {
+	weights := make(data.CardWeights)
+	for _, w := range wishlists {
+		for n, wt := range w.WeightsWithCollection(expansionId, missing) {
+			weights[n] = max(weights[n], wt)
+		}
+	}
+	return weights
+}
@@ -0,0 +1,88 @@
+package userdata
+
+import (
+	"os"
+	"path/filepath"
+	"ptcgpocket/data"
+	"ptcgpocket/internal/testexpansion"
+	"testing"
+)
+
+func TestReadWishlistPriorities(t *testing.T) {
+	var cards []*data.Card
+	for n := range data.ExpansionCardNumber(4) {
+		cards = append(cards, data.NewCard(data.NewBaseCard("Test", 100, 0), n+1, data.RarityOneDiamond))
+	}
+	expansion := testexpansion.New("test", cards)
+
+	dataFilepath := filepath.Join(t.TempDir(), "data.json")
+	raw := `{
+		"collection": {"test": {"packPoints": 0, "missing": [1, 2, 3, 4]}},
+		"wishlists": {"deck": {"test": [
+			1,
+			{"number": 2, "priority": "must-have"},
+			{"number": 3, "priority": "nice-to-have"},
+			{"number": 4, "weight": 10, "copies": 2}
+		]}}
+	}`
+	if err := os.WriteFile(dataFilepath, []byte(raw), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	userData, err := ReadFromFilepath(dataFilepath, []*data.Expansion{expansion})
+	if err != nil {
+		t.Fatalf("ReadFromFilepath error = %v; want nil", err)
+	}
+	var wishlist *Wishlist
+	for w := range userData.Wishlists() {
+		wishlist = w
+	}
+	weights, found := wishlist.WeightsForExpansion("test")
+	if !found {
+		t.Fatalf("Wishlist weights for expansion not found")
+	}
+	want := data.CardWeights{1: 2, 2: 4, 3: 1, 4: 10}
+	for n, w := range want {
+		if weights[n] != w {
+			t.Errorf("Weight of card %v = %v; want %v", n, weights[n], w)
+		}
+	}
+
+	if copies := wishlist.CopiesWanted("test", cards[3]); copies != 2 {
+		t.Errorf("Wanted copies of card 4 = %v; want 2", copies)
+	}
+	if copies := wishlist.CopiesWanted("test", cards[0]); copies != 1 {
+		t.Errorf("Wanted copies of card 1 = %v; want 1", copies)
+	}
+
+	missing, _ := userData.Collection().MissingSetForExpansion("test")
+	missing = missing.Clone()
+	missing.AddNumber(5)
+	merged := MergeWishlistWeights([]*Wishlist{wishlist}, "test", missing)
+	if merged[2] != 4 || merged[5] != CollectionCardWeight {
+		t.Errorf("Merged weights = %v; want card 2 weight 4 and card 5 weight %v", merged, CollectionCardWeight)
+	}
+}
+
+func TestReadWishlistInvalidCard(t *testing.T) {
+	expansion := testexpansion.New("test", []*data.Card{
+		data.NewCard(data.NewBaseCard("Test", 100, 0), 1, data.RarityOneDiamond),
+	})
+	for _, card := range []string{
+		`{"number": 1, "priority": "urgent"}`,
+		`{"number": 1, "copies": 0}`,
+		`{"number": 1, "weight": 0}`,
+		`{"number": 1, "weight": -1}`,
+		`{"number": 1, "weight": 2, "priority": "must-have"}`,
+	} {
+		dataFilepath := filepath.Join(t.TempDir(), "data.json")
+		raw := `{"collection": {}, "wishlists": {"deck": {"test": [` + card + `]}}}`
+		if err := os.WriteFile(dataFilepath, []byte(raw), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := ReadFromFilepath(dataFilepath, []*data.Expansion{expansion}); err == nil {
+			t.Errorf("ReadFromFilepath of %v error = nil; want error", card)
+		}
+	}
+}
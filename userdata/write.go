@@ -0,0 +1,32 @@
+package userdata
+
+import (
+	"encoding/json"
+	"io"
+	"ptcgpocket/data"
+	"slices"
+)
+
+// Writes the collection as a data file, readable by ReadFromFilepath or as a
+// profile of a profiles directory.
+func WriteCollection(w io.Writer, collection *UserCollection) error {
+	serialised := serialisedUserData{
+		Collection: make(map[data.ExpansionId]*serialisedExpansionCollection, len(collection.expansions)),
+	}
+	for eId, c := range collection.expansions {
+		s := &serialisedExpansionCollection{
+			Missing:    slices.Collect(c.missingCards.Numbers()),
+			PackPoints: c.PackPoints(),
+		}
+		if s.Missing == nil {
+			s.Missing = []data.ExpansionCardNumber{}
+		}
+		if duplicates := c.duplicatesByNumber(); len(duplicates) > 0 {
+			s.Duplicates = duplicates
+		}
+		serialised.Collection[eId] = s
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(&serialised)
+}